@@ -0,0 +1,71 @@
+package remote
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"bunnyshell.com/dev/pkg/k8s"
+	"bunnyshell.com/dev/pkg/remote"
+)
+
+func init() {
+	var (
+		namespaceName   string
+		deploymentName  string
+		statefulSetName string
+		daemonSetName   string
+	)
+
+	command := &cobra.Command{
+		Use:   "status",
+		Short: "Show the remote development state without changing it",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			remoteDevelopment := remote.NewRemoteDevelopment()
+			remoteDevelopment.WithKubernetesClient(k8s.GetKubeConfigFilePath())
+
+			// input
+			if namespaceName != "" {
+				remoteDevelopment.WithNamespaceName(namespaceName)
+			} else if err := remoteDevelopment.SelectNamespace(); err != nil {
+				return err
+			}
+
+			if deploymentName != "" {
+				remoteDevelopment.WithDeploymentName(deploymentName)
+			} else if statefulSetName != "" {
+				remoteDevelopment.WithStatefulSetName(statefulSetName)
+			} else if daemonSetName != "" {
+				remoteDevelopment.WithDaemonSetName(daemonSetName)
+			} else {
+				if err := remoteDevelopment.SelectResource(); err != nil {
+					return err
+				}
+			}
+
+			installed, version := remoteDevelopment.IsBinaryInstalled()
+			fmt.Printf("Mutagen installed: %t %s\n", installed, version)
+			fmt.Printf("Mutagen daemon running: %t\n", remoteDevelopment.IsDaemonRunning())
+
+			if err := remoteDevelopment.AttachExistingSession(); err != nil {
+				fmt.Println("Mutagen session active: false")
+				return nil
+			}
+
+			active, err := remoteDevelopment.IsSessionActive()
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Mutagen session active: %t\n", active)
+
+			return nil
+		},
+	}
+
+	command.Flags().StringVarP(&namespaceName, "namespace", "n", "", "Kubernetes Namespace")
+	command.Flags().StringVarP(&deploymentName, "deployment", "d", "", "Kubernetes Deployment")
+	command.Flags().StringVarP(&statefulSetName, "statefulset", "s", "", "Kubernetes StatefulSet")
+	command.Flags().StringVarP(&daemonSetName, "daemonset", "t", "", "Kubernetes DaemonSet")
+
+	mainCmd.AddCommand(command)
+}
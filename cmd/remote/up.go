@@ -47,6 +47,9 @@ func init() {
 		syncMode       syncMode = twoWayResolved
 		localSyncPath  string
 		remoteSyncPath string
+		reuseSession   bool
+
+		ignoreLargeBinaries bool
 
 		portMappings []string
 
@@ -61,7 +64,8 @@ func init() {
 			remoteDevelopment.
 				WithKubernetesClient(k8s.GetKubeConfigFilePath()).
 				WithWaitTimeout(int64(waitTimeout)).
-				WithSyncMode(syncModeToMutagenMode[syncMode])
+				WithSyncMode(syncModeToMutagenMode[syncMode]).
+				WithReuseSession(reuseSession)
 
 			// wizard
 			if namespaceName != "" {
@@ -100,6 +104,10 @@ func init() {
 				return err
 			}
 
+			if ignoreLargeBinaries {
+				remoteDevelopment.WithIgnoreLargeBinaries()
+			}
+
 			if len(portMappings) > 0 {
 				if err := remoteDevelopment.PrepareSSHTunnels(portMappings); err != nil {
 					return err
@@ -129,6 +137,8 @@ func init() {
 	command.Flags().StringVar(&containerName, "container", "", "Kubernetes Container")
 	command.Flags().StringVarP(&localSyncPath, "local-sync-path", "l", "", "Local folder path to sync")
 	command.Flags().StringVarP(&remoteSyncPath, "remote-sync-path", "r", "", "Remote folder path to sync")
+	command.Flags().BoolVar(&reuseSession, "reuse-session", false, "Keep the sync session running on exit and reuse it on the next start")
+	command.Flags().BoolVar(&ignoreLargeBinaries, "ignore-large-binaries", false, "Exclude common large binary files (archives, videos, databases) from sync")
 	command.Flags().StringSliceVarP(&portMappings, "portforward", "p", []string{}, "Port forward: '8080>3000'\nReverse port forward: '9003<9003'\nComma separated: '8080>3000,9003<9003'")
 	command.Flags().IntVarP(&waitTimeout, "wait-timeout", "w", 120, "Time to wait for pod to be ready")
 	command.Flags().BoolVar(&noTTY, "no-tty", false, "Start remote development with no ssh terminal")
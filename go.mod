@@ -8,6 +8,7 @@ replace github.com/imdario/mergo => github.com/imdario/mergo v0.3.16
 
 require (
 	github.com/AlecAivazis/survey/v2 v2.3.7
+	github.com/ProtonMail/go-crypto v1.1.6
 	github.com/briandowns/spinner v1.23.0
 	github.com/kevinburke/ssh_config v1.2.0
 	github.com/shiena/ansicolor v0.0.0-20230509054315-a9deabde6e02
@@ -15,6 +16,7 @@ require (
 	github.com/thediveo/enumflag/v2 v2.0.5
 	golang.org/x/crypto v0.21.0
 	golang.org/x/mod v0.16.0
+	golang.org/x/sys v0.18.0
 	golang.org/x/term v0.18.0
 	gopkg.in/yaml.v3 v3.0.1
 	k8s.io/api v0.29.3
@@ -23,6 +25,7 @@ require (
 )
 
 require (
+	github.com/cloudflare/circl v1.3.7 // indirect
 	github.com/davecgh/go-spew v1.1.2-0.20180830191138-d8f796af33cc // indirect
 	github.com/emicklei/go-restful/v3 v3.12.0 // indirect
 	github.com/fatih/color v1.16.0 // indirect
@@ -53,7 +56,6 @@ require (
 	golang.org/x/exp v0.0.0-20240325151524-a685a6edb6d8 // indirect
 	golang.org/x/net v0.23.0 // indirect
 	golang.org/x/oauth2 v0.18.0 // indirect
-	golang.org/x/sys v0.18.0 // indirect
 	golang.org/x/text v0.14.0 // indirect
 	golang.org/x/time v0.5.0 // indirect
 	google.golang.org/appengine v1.6.8 // indirect
@@ -0,0 +1,55 @@
+package config
+
+import "slices"
+
+// +enum
+type IgnoreGroup string
+
+const (
+	// IgnoreGroupVCS toggles mutagen's own VCS ignores, e.g. .git, rather than paths.
+	// Mutagen applies ignores to the whole session, there is no per-endpoint setting: an ignore on one side only
+	// would read as a deletion to the other side. In two-way modes, syncing the VCS dirs of both sides
+	// reconciles them file by file, which corrupts repositories changed on both sides.
+	IgnoreGroupVCS           IgnoreGroup = "vcs"
+	IgnoreGroupNode          IgnoreGroup = "node"
+	IgnoreGroupPython        IgnoreGroup = "python"
+	IgnoreGroupEditor        IgnoreGroup = "editor"
+	IgnoreGroupLargeBinaries IgnoreGroup = "large-binaries"
+	// IgnoreGroupCustom holds the caller's own paths
+	IgnoreGroupCustom IgnoreGroup = "custom"
+)
+
+// IgnoreGroupPaths are the paths of the predefined groups, large binaries being covered by WithLargeBinaries
+var IgnoreGroupPaths = map[IgnoreGroup][]string{
+	IgnoreGroupNode:   {"node_modules/", ".npm/", ".yarn/cache/", ".pnpm-store/"},
+	IgnoreGroupPython: {"__pycache__/", "*.pyc", ".venv/", ".pytest_cache/", ".mypy_cache/", ".tox/"},
+	IgnoreGroupEditor: {".idea/", ".vscode/", "*.swp", "*~", ".DS_Store"},
+}
+
+// IgnoreGroupOrder is the order groups are assembled in, later paths override earlier ones
+var IgnoreGroupOrder = []IgnoreGroup{
+	IgnoreGroupVCS,
+	IgnoreGroupNode,
+	IgnoreGroupPython,
+	IgnoreGroupEditor,
+	IgnoreGroupLargeBinaries,
+	IgnoreGroupCustom,
+}
+
+// Deduplicate keeps the last occurrence of each path. The last matching path wins, so the outcome is unchanged.
+func (i *Ignore) Deduplicate() *Ignore {
+	seen := map[string]bool{}
+	paths := []string{}
+	for index := len(i.Paths) - 1; index >= 0; index-- {
+		if seen[i.Paths[index]] {
+			continue
+		}
+
+		seen[i.Paths[index]] = true
+		paths = append(paths, i.Paths[index])
+	}
+	slices.Reverse(paths)
+
+	i.Paths = paths
+	return i
+}
@@ -0,0 +1,21 @@
+package config
+
+import "strings"
+
+// LargeBinaryExtensions are rarely meant to be synced and slow down transfers
+var LargeBinaryExtensions = []string{
+	"iso", "img", "dmg",
+	"mp4", "mov", "avi", "mkv",
+	"zip", "tar", "gz", "tgz", "7z", "rar",
+	"db", "sqlite", "sqlite3", "dump",
+}
+
+// WithLargeBinaries ignores LargeBinaryExtensions plus any extra extensions, e.g. "bin" or ".bin"
+func (i *Ignore) WithLargeBinaries(extraExtensions ...string) *Ignore {
+	extensions := append(append([]string{}, LargeBinaryExtensions...), extraExtensions...)
+	for _, extension := range extensions {
+		i.Paths = append(i.Paths, "*."+strings.TrimPrefix(strings.TrimPrefix(extension, "*"), "."))
+	}
+
+	return i
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"reflect"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Marshal is deterministic: struct fields follow their declaration order and the config holds no maps
+func (c *Configuration) Marshal() ([]byte, error) {
+	return yaml.Marshal(c)
+}
+
+func Parse(data []byte) (*Configuration, error) {
+	config := NewConfiguration()
+	if err := yaml.Unmarshal(data, config); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// Matches compares c with a marshaled config semantically, ignoring formatting and key order.
+// c goes through a marshal round-trip first, so unset and empty values compare equal.
+func (c *Configuration) Matches(data []byte) (bool, error) {
+	desiredData, err := c.Marshal()
+	if err != nil {
+		return false, err
+	}
+
+	desired, err := Parse(desiredData)
+	if err != nil {
+		return false, err
+	}
+
+	existing, err := Parse(data)
+	if err != nil {
+		return false, err
+	}
+
+	return reflect.DeepEqual(desired, existing), nil
+}
@@ -1,8 +1,10 @@
 package config
 
 type SyncDefaults struct {
-	Mode   Mode    `yaml:",omitempty"`
-	Ignore *Ignore `yaml:",omitempty"`
+	Mode     Mode     `yaml:",omitempty"`
+	ScanMode ScanMode `yaml:"scanMode,omitempty"`
+	Ignore   *Ignore  `yaml:",omitempty"`
+	Watch    *Watch   `yaml:",omitempty"`
 }
 
 func NewSyncDefaults() *SyncDefaults {
@@ -14,7 +16,17 @@ func (d *SyncDefaults) WithMode(mode Mode) *SyncDefaults {
 	return d
 }
 
+func (d *SyncDefaults) WithScanMode(scanMode ScanMode) *SyncDefaults {
+	d.ScanMode = scanMode
+	return d
+}
+
 func (d *SyncDefaults) WithIgnore(ignore *Ignore) *SyncDefaults {
 	d.Ignore = ignore
 	return d
 }
+
+func (d *SyncDefaults) WithWatch(watch *Watch) *SyncDefaults {
+	d.Watch = watch
+	return d
+}
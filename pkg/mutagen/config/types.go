@@ -10,3 +10,25 @@ const (
 	OneWaySafe     Mode = "one-way-safe"
 	OneWayReplica  Mode = "one-way-replica"
 )
+
+// +enum
+type ScanMode string
+
+const (
+	// ScanModeFull rescans the whole tree on every cycle
+	ScanModeFull ScanMode = "full"
+	// ScanModeAccelerated reuses the previous scan and only rescans paths reported by the watcher
+	ScanModeAccelerated ScanMode = "accelerated"
+)
+
+// +enum
+type WatchMode string
+
+const (
+	// WatchModePortable uses the native filesystem events where available, polling otherwise
+	WatchModePortable WatchMode = "portable"
+	// WatchModeForcePoll always polls, see Watch.PollingInterval
+	WatchModeForcePoll WatchMode = "force-poll"
+	// WatchModeNoWatch disables watching, cycles only run when flushed
+	WatchModeNoWatch WatchMode = "no-watch"
+)
@@ -0,0 +1,21 @@
+package config
+
+type Watch struct {
+	Mode WatchMode `yaml:",omitempty"`
+	// PollingInterval is in seconds
+	PollingInterval uint32 `yaml:"pollingInterval,omitempty"`
+}
+
+func NewWatch() *Watch {
+	return &Watch{}
+}
+
+func (w *Watch) WithMode(mode WatchMode) *Watch {
+	w.Mode = mode
+	return w
+}
+
+func (w *Watch) WithPollingInterval(pollingInterval uint32) *Watch {
+	w.PollingInterval = pollingInterval
+	return w
+}
@@ -0,0 +1,90 @@
+package remote
+
+import (
+	"fmt"
+	"strings"
+
+	mutagenConfig "bunnyshell.com/dev/pkg/mutagen/config"
+	bunnyshellSSH "bunnyshell.com/dev/pkg/ssh"
+)
+
+const (
+	abortRemoveBatchSize = 200
+)
+
+// AbortSync terminates the session, e.g. an initial sync started on the wrong directory, and reports how far it got.
+// With WithPurgeRemoteOnAbort the remote copies of the local files are removed as well.
+func (r *RemoteDevelopment) AbortSync() error {
+	if r.syncMode == mutagenConfig.None {
+		return nil
+	}
+
+	progress := "unknown"
+	session, err := r.getMutagenSession()
+	if err != nil {
+		return err
+	}
+	if session != nil {
+		progress = session.Progress()
+	}
+
+	if err := r.terminateMutagenSession(); err != nil {
+		return err
+	}
+
+	if err := r.removeSessionState(); err != nil {
+		return err
+	}
+
+	fmt.Printf("INFO: sync aborted, last progress: %s\n", progress)
+
+	if !r.purgeRemoteOnAbort {
+		return nil
+	}
+
+	return r.removeTransferredRemoteFiles()
+}
+
+// removeTransferredRemoteFiles deletes the remote files matching a local file path, directories are left in place.
+// Remote files which existed before the sync under the same path are deleted too.
+func (r *RemoteDevelopment) removeTransferredRemoteFiles() error {
+	ignore, err := r.getMutagenIgnore()
+	if err != nil {
+		return err
+	}
+	ignores := append([]string{".git"}, ignore.Paths...)
+
+	batch := []string{}
+	removed := 0
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+
+		command := fmt.Sprintf("cd %s && rm -f -- %s", bunnyshellSSH.QuoteArg(r.remoteSyncPath), strings.Join(batch, " "))
+		if output, err := r.runRemoteCommand(command); err != nil {
+			return fmt.Errorf("cannot remove remote files in %s: %w: %s", r.remoteSyncPath, err, strings.TrimSpace(string(output)))
+		}
+
+		removed += len(batch)
+		batch = batch[:0]
+
+		return nil
+	}
+
+	err = walkLocalFiles(r.localSyncPath, ignores, func(relativePath, filePath string) error {
+		batch = append(batch, bunnyshellSSH.QuoteArg(relativePath))
+		if len(batch) < abortRemoveBatchSize {
+			return nil
+		}
+
+		return flush()
+	})
+	if err == nil {
+		err = flush()
+	}
+
+	fmt.Printf("INFO: removed %d remote files from %s\n", removed, r.remoteSyncPath)
+
+	return err
+}
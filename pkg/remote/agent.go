@@ -0,0 +1,61 @@
+package remote
+
+import (
+	"fmt"
+	"strings"
+
+	bunnyshellSSH "bunnyshell.com/dev/pkg/ssh"
+)
+
+const (
+	defaultRemoteShell = "sh"
+
+	mutagenAgentInstallFailure = "install agent"
+
+	// mutagenAgentProbeCommand prints the agent directory when it is not writable. Mutagen copies its agent
+	// to ~/.mutagen of the remote user, containers with a read-only or missing home directory cannot run it.
+	mutagenAgentProbeCommand = `d="${HOME:-/}/.mutagen"; if mkdir -p "$d" 2>/dev/null && touch "$d/.probe" 2>/dev/null && rm -f "$d/.probe"; then exit 0; fi; echo "$d"; exit 1`
+)
+
+var (
+	ErrAgentInstallFailed = fmt.Errorf("mutagen cannot install its agent on the remote")
+)
+
+// wrapRemoteShell runs command through the configured remote shell, the login shell of the remote user otherwise
+func (r *RemoteDevelopment) wrapRemoteShell(command string) string {
+	if r.remoteShell == "" {
+		return command
+	}
+
+	return fmt.Sprintf("%s -c %s", r.remoteShell, bunnyshellSSH.QuoteArg(command))
+}
+
+func (r *RemoteDevelopment) getRemoteShell() string {
+	if r.remoteShell == "" {
+		return defaultRemoteShell
+	}
+
+	return r.remoteShell
+}
+
+// diagnoseMutagenAgentInstall turns mutagen's agent installation failures into an actionable error
+func (r *RemoteDevelopment) diagnoseMutagenAgentInstall(createErr error, output []byte) error {
+	if !strings.Contains(strings.ToLower(string(output)), mutagenAgentInstallFailure) {
+		return createErr
+	}
+
+	probeOutput, err := r.runRemoteCommand(mutagenAgentProbeCommand)
+	if err != nil && len(probeOutput) > 0 {
+		return fmt.Errorf(
+			"%w: %s is not writable, the container needs a writable home directory for the remote user",
+			ErrAgentInstallFailed,
+			strings.TrimSpace(string(probeOutput)),
+		)
+	}
+
+	return fmt.Errorf(
+		"%w: the remote user needs a POSIX shell and a writable home directory: %s",
+		ErrAgentInstallFailed,
+		strings.TrimSpace(string(output)),
+	)
+}
@@ -0,0 +1,82 @@
+package remote
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+
+	mutagenConfig "bunnyshell.com/dev/pkg/mutagen/config"
+	bunnyshellSSH "bunnyshell.com/dev/pkg/ssh"
+)
+
+var (
+	ErrCaseConflict = fmt.Errorf("remote paths differ only in case")
+)
+
+// hasCaseInsensitiveFilesystem reports the platform default, e.g. APFS on macOS and NTFS on windows.
+// Case-sensitive volumes exist on both, the check can be disabled for them.
+func hasCaseInsensitiveFilesystem() bool {
+	return runtime.GOOS == "darwin" || runtime.GOOS == "windows"
+}
+
+// verifyNoCaseConflicts fails when the remote holds paths like "Readme.md" and "README.md",
+// which a case-insensitive local filesystem cannot represent side by side
+func (r *RemoteDevelopment) verifyNoCaseConflicts() error {
+	if !r.checkCaseConflicts || r.syncMode == mutagenConfig.None {
+		return nil
+	}
+
+	r.startStep(StepCheckCaseConflicts)
+	defer r.StopSpinner()
+
+	ignore, err := r.getMutagenIgnore()
+	if err != nil {
+		return err
+	}
+	ignores := append([]string{".git"}, ignore.Paths...)
+
+	// a missing remote sync path has nothing to conflict with
+	command := fmt.Sprintf("cd %s 2>/dev/null || exit 0; find . -mindepth 1", bunnyshellSSH.QuoteArg(r.remoteSyncPath))
+	output, err := r.runRemoteCommand(command)
+	if err != nil {
+		return fmt.Errorf("cannot list remote paths in %s: %w: %s", r.remoteSyncPath, err, strings.TrimSpace(string(output)))
+	}
+
+	paths := map[string][]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		filePath := strings.TrimPrefix(scanner.Text(), "./")
+		if isIgnoredPath(filePath, ignores) {
+			continue
+		}
+
+		folded := strings.ToLower(filePath)
+		paths[folded] = append(paths[folded], filePath)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	conflicts := []string{}
+	for _, variants := range paths {
+		if len(variants) > 1 {
+			sort.Strings(variants)
+			conflicts = append(conflicts, strings.Join(variants, " / "))
+		}
+	}
+	if len(conflicts) == 0 {
+		return nil
+	}
+
+	sort.Strings(conflicts)
+
+	return fmt.Errorf(
+		"%w in %s, rename them or disable the check on a case-sensitive local filesystem: %s",
+		ErrCaseConflict,
+		r.remoteSyncPath,
+		strings.Join(conflicts, ", "),
+	)
+}
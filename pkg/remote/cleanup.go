@@ -0,0 +1,57 @@
+package remote
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TerminateSessionsOlderThan terminates the sessions created by this tool, of any deployment, older than age.
+// Ownership is decided by the session key label and the session name prefix, other mutagen sessions are never touched.
+func (r *RemoteDevelopment) TerminateSessionsOlderThan(age time.Duration) ([]string, error) {
+	mutagenBinPath, err := r.getMutagenBinPath()
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := r.listMutagenSessions()
+	if err != nil {
+		return nil, err
+	}
+
+	terminated := []string{}
+	errs := []string{}
+	for _, session := range sessions {
+		if !r.ownsMutagenSession(session) {
+			continue
+		}
+
+		// an unknown age is never old enough
+		creationTime, err := time.Parse(time.RFC3339Nano, session.CreationTime)
+		if err != nil || time.Since(creationTime) < age {
+			continue
+		}
+
+		output, err := r.newMutagenCommand(mutagenBinPath, "sync", "terminate", session.Identifier).CombinedOutput()
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", session.Name, strings.TrimSpace(string(output))))
+			continue
+		}
+
+		terminated = append(terminated, session.Name)
+	}
+
+	if len(errs) > 0 {
+		return terminated, fmt.Errorf("cannot terminate sessions: %s", strings.Join(errs, "; "))
+	}
+
+	return terminated, nil
+}
+
+func (r *RemoteDevelopment) ownsMutagenSession(session MutagenSession) bool {
+	if _, ok := session.Labels[mutagenLabelSessionKey]; !ok {
+		return false
+	}
+
+	return strings.HasPrefix(session.Name, r.sessionNamePrefix)
+}
@@ -0,0 +1,119 @@
+package remote
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	mutagenConfig "bunnyshell.com/dev/pkg/mutagen/config"
+	"bunnyshell.com/dev/pkg/util"
+)
+
+// +enum
+type ConfigDriftPolicy string
+
+const (
+	// ConfigDriftIgnore keeps a drifted session running, the default
+	ConfigDriftIgnore ConfigDriftPolicy = "ignore"
+	// ConfigDriftWarn keeps a drifted session running with a warning
+	ConfigDriftWarn ConfigDriftPolicy = "warn"
+	// ConfigDriftPrompt asks whether to recreate a drifted session
+	ConfigDriftPrompt ConfigDriftPolicy = "prompt"
+	// ConfigDriftRecreate recreates a drifted session from the current settings
+	ConfigDriftRecreate ConfigDriftPolicy = "recreate"
+
+	// mutagenLabelConfigHash records the settings a session was created with, sessions are immutable
+	mutagenLabelConfigHash = "remote-dev.bunnyshell.com/config-hash"
+
+	configHashLength = 16
+)
+
+// getMutagenConfigHash is computed over the canonical form, so formatting and key order don't count as drift
+func getMutagenConfigHash(data []byte) (string, error) {
+	config, err := mutagenConfig.Parse(data)
+	if err != nil {
+		return "", err
+	}
+
+	canonicalData, err := config.Marshal()
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.Sum256(canonicalData)
+	return hex.EncodeToString(hash[:])[:configHashLength], nil
+}
+
+// getDesiredConfigHash hashes the config derived from the current settings, leaving out the ignores resolved
+// from the local tree: the .gitignore patterns and the stale files change without the settings changing,
+// the latter as files age. It neither walks the tree nor prints, so it can be polled.
+func (r *RemoteDevelopment) getDesiredConfigHash() (string, error) {
+	ignore, err := r.getSettingsMutagenIgnore()
+	if err != nil {
+		return "", err
+	}
+
+	data, err := r.newMutagenConfig(ignore).Marshal()
+	if err != nil {
+		return "", err
+	}
+
+	return getMutagenConfigHash(data)
+}
+
+// DetectConfigDrift tells whether the running session was created from other settings than the current ones,
+// through the hash recorded in its labels. Sessions created without the hash are not compared.
+func (r *RemoteDevelopment) DetectConfigDrift() (bool, error) {
+	if r.syncMode == mutagenConfig.None {
+		return false, nil
+	}
+
+	session, err := r.getMutagenSession()
+	if err != nil || session == nil {
+		return false, err
+	}
+
+	sessionHash, ok := session.Labels[mutagenLabelConfigHash]
+	if !ok {
+		return false, nil
+	}
+
+	desiredHash, err := r.getDesiredConfigHash()
+	if err != nil {
+		return false, err
+	}
+
+	return sessionHash != desiredHash, nil
+}
+
+// shouldRecreateOnConfigDrift applies the drift policy, the caller recreates the session when told to.
+// Without a terminal to prompt on, e.g. while watching, the prompt policy falls back to warning.
+func (r *RemoteDevelopment) shouldRecreateOnConfigDrift(canPrompt bool) (bool, error) {
+	if r.configDriftPolicy == "" || r.configDriftPolicy == ConfigDriftIgnore {
+		return false, nil
+	}
+
+	drifted, err := r.DetectConfigDrift()
+	if err != nil || !drifted {
+		return false, err
+	}
+
+	switch {
+	case r.configDriftPolicy == ConfigDriftRecreate:
+		fmt.Printf("INFO: recreating the mutagen session to apply the current settings\n")
+		return true, nil
+	case r.configDriftPolicy == ConfigDriftPrompt && canPrompt:
+		recreate := false
+		r.pauseSpinner(func() {
+			recreate, err = util.Confirm("The mutagen session config no longer matches the current settings. Recreate the session?", true)
+		})
+
+		return recreate, err
+	}
+
+	r.configDriftWarnOnce.Do(func() {
+		fmt.Printf("WARNING: the mutagen session config no longer matches the current settings, restart the session to apply them\n")
+	})
+
+	return false, nil
+}
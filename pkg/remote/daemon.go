@@ -0,0 +1,53 @@
+package remote
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	mutagenDaemonVersionMismatch = "version mismatch"
+)
+
+var (
+	ErrDaemonVersionMismatch = fmt.Errorf("a mutagen daemon of another version is running")
+)
+
+// ensureMutagenDaemonCompatible detects a daemon started by another mutagen install, e.g. a system one,
+// which our binary cannot talk to. It is restarted with our binary when allowed, sessions persist across restarts.
+func (r *RemoteDevelopment) ensureMutagenDaemonCompatible() error {
+	mutagenBinPath, err := r.getMutagenBinPath()
+	if err != nil {
+		return err
+	}
+
+	mutagenCmd := r.newMutagenCommand(mutagenBinPath, "sync", "list", "--template", mutagenListTemplate)
+	mutagenCmd.Env = append(mutagenCmd.Environ(), mutagenDisableAutostartEnv)
+
+	// a daemon which is not running is started by our binary on first use
+	output, err := mutagenCmd.CombinedOutput()
+	if err == nil || !strings.Contains(string(output), mutagenDaemonVersionMismatch) {
+		return nil
+	}
+
+	if !r.restartIncompatibleDaemon {
+		return fmt.Errorf(
+			"%w, stop it with \"mutagen daemon stop\" using the mutagen which started it, or run \"%s daemon stop\"",
+			ErrDaemonVersionMismatch,
+			mutagenBinPath,
+		)
+	}
+
+	fmt.Printf("INFO: restarting the mutagen daemon with %s\n", mutagenBinPath)
+
+	// "daemon stop" does not enforce the version match
+	if output, err := r.newMutagenCommand(mutagenBinPath, "daemon", "stop").CombinedOutput(); err != nil {
+		return fmt.Errorf("cannot stop the incompatible mutagen daemon: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+
+	if output, err := r.newMutagenCommand(mutagenBinPath, "daemon", "start").CombinedOutput(); err != nil {
+		return fmt.Errorf("cannot start the mutagen daemon: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+
+	return nil
+}
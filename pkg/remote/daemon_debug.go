@@ -0,0 +1,109 @@
+package remote
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+const (
+	mutagenDebugLogLevelEnv = "MUTAGEN_LOG_LEVEL=debug"
+
+	debugDaemonStartTimeout = 10 * time.Second
+)
+
+type debugDaemon struct {
+	cmd  *exec.Cmd
+	done chan error
+}
+
+// syncLogWriter forwards the daemon output to the sync log, line by line
+type syncLogWriter struct {
+	remoteDevelopment *RemoteDevelopment
+}
+
+func (w *syncLogWriter) Write(p []byte) (int, error) {
+	for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+		w.remoteDevelopment.logSync("daemon: %s", line)
+	}
+
+	return len(p), nil
+}
+
+// startDebugDaemon replaces the background daemon with one attached to our process, logging at debug level
+// to the sync log when configured, to stderr otherwise. Autostart is disabled so a crash is not hidden
+// by a new background daemon.
+func (r *RemoteDevelopment) startDebugDaemon() error {
+	mutagenBinPath, err := r.getMutagenBinPath()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("INFO: running the mutagen daemon in the foreground for debugging\n")
+
+	// "daemon stop" does not enforce the version match and fails when no daemon is running
+	r.newMutagenCommand(mutagenBinPath, "daemon", "stop").Run()
+
+	r.mutagenEnv = append(r.mutagenEnv, mutagenDisableAutostartEnv)
+
+	var output io.Writer = os.Stderr
+	if r.syncLog != nil {
+		output = &syncLogWriter{remoteDevelopment: r}
+	}
+
+	daemonCmd := r.newMutagenCommand(mutagenBinPath, "daemon", "run")
+	daemonCmd.Env = append(daemonCmd.Environ(), mutagenDebugLogLevelEnv)
+	daemonCmd.Stdout = output
+	daemonCmd.Stderr = output
+
+	if err := daemonCmd.Start(); err != nil {
+		return fmt.Errorf("cannot run the mutagen daemon: %w", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- daemonCmd.Wait()
+	}()
+
+	r.debugDaemon = &debugDaemon{cmd: daemonCmd, done: done}
+
+	return r.waitDebugDaemon()
+}
+
+func (r *RemoteDevelopment) waitDebugDaemon() error {
+	timeout := time.After(debugDaemonStartTimeout)
+	for {
+		if _, err := r.listMutagenSessions(); err == nil {
+			return nil
+		}
+
+		select {
+		case err := <-r.debugDaemon.done:
+			r.debugDaemon = nil
+			return fmt.Errorf("the mutagen daemon exited: %v", err)
+		case <-timeout:
+			return fmt.Errorf("the mutagen daemon did not start in %s", debugDaemonStartTimeout)
+		case <-time.After(syncPollInterval / 4):
+		}
+	}
+}
+
+// stopDebugDaemon stops the foreground daemon, the sessions are resumed by the next daemon started
+func (r *RemoteDevelopment) stopDebugDaemon() {
+	if r.debugDaemon == nil {
+		return
+	}
+
+	r.terminateMutagenDaemon()
+
+	select {
+	case <-r.debugDaemon.done:
+	case <-time.After(debugDaemonStartTimeout):
+		r.debugDaemon.cmd.Process.Kill()
+	}
+
+	r.debugDaemon = nil
+}
@@ -0,0 +1,64 @@
+package remote
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	mutagenConfig "bunnyshell.com/dev/pkg/mutagen/config"
+)
+
+// getMutagenWatch disables mutagen's watch when debouncing, cycles are then triggered by runSyncDebounce
+func (r *RemoteDevelopment) getMutagenWatch() *mutagenConfig.Watch {
+	if r.syncDebounce <= 0 {
+		return nil
+	}
+
+	return mutagenConfig.NewWatch().WithMode(mutagenConfig.WatchModeNoWatch)
+}
+
+// startSyncDebounce runs one sync cycle per debounce interval until Close
+func (r *RemoteDevelopment) startSyncDebounce() {
+	if r.syncDebounce <= 0 || r.syncMode == mutagenConfig.None {
+		return
+	}
+
+	go r.runSyncDebounce()
+}
+
+func (r *RemoteDevelopment) runSyncDebounce() {
+	ticker := time.NewTicker(r.syncDebounce)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.stopChannel:
+			return
+		case <-ticker.C:
+		}
+
+		if err := r.triggerSyncCycle(); err != nil {
+			fmt.Printf("WARNING: %s\n", err)
+		}
+	}
+}
+
+// triggerSyncCycle starts a cycle without waiting for it, a cycle in progress is not interrupted
+func (r *RemoteDevelopment) triggerSyncCycle() error {
+	mutagenBinPath, err := r.getMutagenBinPath()
+	if err != nil {
+		return err
+	}
+
+	sessionName, err := r.getMutagenSessionName()
+	if err != nil {
+		return err
+	}
+
+	output, err := r.newMutagenCommand(mutagenBinPath, "sync", "flush", "--skip-wait", sessionName).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("cannot trigger a sync cycle of %s: %w: %s", sessionName, err, strings.TrimSpace(string(output)))
+	}
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package remote
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"bunnyshell.com/dev/pkg/util"
+)
+
+const (
+	// deploymentsDirname holds one subdirectory per session key, the mutagen binary and the SSH keys stay shared
+	deploymentsDirname = "deployments"
+
+	deploymentDirPermissionMask = 0700
+)
+
+// legacyMutagenConfigFilePattern matches the config files once kept in the workspace root, named after the session key
+var legacyMutagenConfigFilePattern = "mutagen." + strings.Repeat("?", mutagenSessionKeyLength) + ".yaml"
+
+// getDeploymentWorkspaceDir returns the workspace subdirectory of the deployment and remote path, creating it
+func (r *RemoteDevelopment) getDeploymentWorkspaceDir() (string, error) {
+	deploymentDir, err := r.getDeploymentWorkspaceDirPath()
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(deploymentDir, deploymentDirPermissionMask); err != nil {
+		return "", fmt.Errorf("cannot create deployment workspace %s: %w", deploymentDir, err)
+	}
+
+	return deploymentDir, nil
+}
+
+// getDeploymentWorkspaceDirPath resolves the subdirectory without creating it
+func (r *RemoteDevelopment) getDeploymentWorkspaceDirPath() (string, error) {
+	deploymentsDir, err := getDeploymentsDirPath()
+	if err != nil {
+		return "", err
+	}
+
+	sessionKey, err := r.getMutagenSessionKey()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(deploymentsDir, sessionKey), nil
+}
+
+func getDeploymentsDirPath() (string, error) {
+	workspaceDir, err := util.GetRemoteDevWorkspaceDirPath()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(workspaceDir, deploymentsDirname), nil
+}
+
+// removeDeploymentWorkspaceDir deletes the config and state files of the deployment, an explicit WithConfigFilePath
+// outside of the subdirectory is left alone
+func (r *RemoteDevelopment) removeDeploymentWorkspaceDir() error {
+	deploymentDir, err := r.getDeploymentWorkspaceDirPath()
+	if err != nil {
+		return err
+	}
+
+	if err := os.RemoveAll(deploymentDir); err != nil {
+		return fmt.Errorf("cannot remove deployment workspace %s: %w", deploymentDir, err)
+	}
+
+	return nil
+}
+
+// removeLegacyMutagenConfigFiles removes the config files left in the workspace root by previous versions,
+// they are generated again in the deployment workspace, see getMutagenConfigFilePath
+func removeLegacyMutagenConfigFiles() {
+	workspaceDir, err := util.GetRemoteDevWorkspaceDirPath()
+	if err != nil {
+		return
+	}
+
+	legacyFilePaths, _ := filepath.Glob(filepath.Join(workspaceDir, legacyMutagenConfigFilePattern))
+	for _, legacyFilePath := range legacyFilePaths {
+		if err := os.Remove(legacyFilePath); err != nil {
+			fmt.Printf("WARNING: cannot remove %s: %s\n", legacyFilePath, err)
+		}
+	}
+}
@@ -0,0 +1,16 @@
+//go:build !windows
+// +build !windows
+
+package remote
+
+import "golang.org/x/sys/unix"
+
+// getFreeDiskSpace returns the bytes available to unprivileged users on the filesystem of path
+func getFreeDiskSpace(path string) (uint64, error) {
+	var stats unix.Statfs_t
+	if err := unix.Statfs(path, &stats); err != nil {
+		return 0, err
+	}
+
+	return uint64(stats.Bavail) * uint64(stats.Bsize), nil
+}
@@ -0,0 +1,18 @@
+package remote
+
+import "golang.org/x/sys/windows"
+
+// getFreeDiskSpace returns the bytes available to the current user on the volume of path
+func getFreeDiskSpace(path string) (uint64, error) {
+	pathPtr, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, err
+	}
+
+	var freeBytesAvailable uint64
+	if err := windows.GetDiskFreeSpaceEx(pathPtr, &freeBytesAvailable, nil, nil); err != nil {
+		return 0, err
+	}
+
+	return freeBytesAvailable, nil
+}
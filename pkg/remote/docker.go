@@ -0,0 +1,90 @@
+package remote
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+const (
+	mutagenDockerScheme = "docker://"
+
+	dockerBinFilename = "docker"
+)
+
+var (
+	dockerContainerNameExp = regexp.MustCompile("^[a-zA-Z0-9][a-zA-Z0-9_.-]*$")
+	dockerUserExp          = regexp.MustCompile("^[a-zA-Z0-9_][a-zA-Z0-9_.:-]*$")
+)
+
+// DockerEndpoint syncs with a container through mutagen's docker transport instead of the SSH tunnel.
+// The docker CLI must be able to reach the container, e.g. via DOCKER_HOST.
+type DockerEndpoint struct {
+	Container string
+	// User runs the mutagen agent and the remote commands, the container's default user when empty
+	User string
+}
+
+func (e *DockerEndpoint) Validate() error {
+	if !dockerContainerNameExp.MatchString(e.Container) {
+		return fmt.Errorf("invalid docker container name %q", e.Container)
+	}
+
+	if e.User != "" && !dockerUserExp.MatchString(e.User) {
+		return fmt.Errorf("invalid docker user %q", e.User)
+	}
+
+	return nil
+}
+
+// url builds the mutagen endpoint, docker://[user@]container/path, paths being absolute within the container
+func (e *DockerEndpoint) url(path string) (string, error) {
+	if !strings.HasPrefix(path, "/") {
+		return "", fmt.Errorf("remote sync path %s must be absolute for docker endpoints", path)
+	}
+
+	host := e.Container
+	if e.User != "" {
+		host = fmt.Sprintf("%s@%s", e.User, e.Container)
+	}
+
+	return mutagenDockerScheme + host + path, nil
+}
+
+func (e *DockerEndpoint) runCommand(shell, command string) ([]byte, error) {
+	return e.newExecCommand(shell, command).CombinedOutput()
+}
+
+// runCommandOutput returns the stdout of command only
+func (e *DockerEndpoint) runCommandOutput(shell, command string) ([]byte, error) {
+	return e.newExecCommand(shell, command).Output()
+}
+
+func (e *DockerEndpoint) newExecCommand(shell, command string) *exec.Cmd {
+	args := []string{"exec"}
+	if e.User != "" {
+		args = append(args, "-u", e.User)
+	}
+	args = append(args, e.Container, shell, "-c", command)
+
+	return exec.Command(dockerBinFilename, args...)
+}
+
+// getMutagenRemoteEndpoint returns the beta endpoint of the session, independent of the transport
+func (r *RemoteDevelopment) getMutagenRemoteEndpoint() (string, error) {
+	if r.dockerEndpoint != nil {
+		if err := r.dockerEndpoint.Validate(); err != nil {
+			return "", err
+		}
+
+		return r.dockerEndpoint.url(r.remoteSyncPath)
+	}
+
+	hostname, err := r.getSSHHostname()
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s:%s", hostname, r.remoteSyncPath), nil
+}
@@ -0,0 +1,153 @@
+package remote
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	bunnyshellSSH "bunnyshell.com/dev/pkg/ssh"
+)
+
+const (
+	// highLatencyThreshold is where syncing many small files becomes noticeably slow
+	highLatencyThreshold = 150 * time.Millisecond
+
+	// clockSkewThreshold is well above the one second resolution of the remote date
+	clockSkewThreshold = 5 * time.Second
+)
+
+// DoctorCheck is the outcome of one diagnostic, Err is set when the check itself could not run
+type DoctorCheck struct {
+	Name    string
+	Result  string
+	Warning string
+	Err     error
+}
+
+// DoctorReport gathers diagnostics explaining a slow or misbehaving sync
+type DoctorReport struct {
+	Checks []DoctorCheck
+}
+
+func (d *DoctorReport) String() string {
+	lines := []string{}
+	for _, check := range d.Checks {
+		switch {
+		case check.Err != nil:
+			lines = append(lines, fmt.Sprintf("%s: ERROR: %s", check.Name, check.Err))
+		case check.Warning != "":
+			lines = append(lines, fmt.Sprintf("%s: %s, WARNING: %s", check.Name, check.Result, check.Warning))
+		default:
+			lines = append(lines, fmt.Sprintf("%s: %s", check.Name, check.Result))
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// Doctor runs the diagnostics against the remote endpoint, which must be reachable, i.e. after Up
+func (r *RemoteDevelopment) Doctor() *DoctorReport {
+	return &DoctorReport{
+		Checks: []DoctorCheck{
+			r.checkLatency(),
+			r.checkClockSkew(),
+		},
+	}
+}
+
+func (r *RemoteDevelopment) checkLatency() DoctorCheck {
+	check := DoctorCheck{Name: "remote latency"}
+
+	latency, err := r.MeasureLatency()
+	if err != nil {
+		check.Err = err
+		return check
+	}
+
+	check.Result = latency.Round(time.Millisecond).String()
+	if latency > highLatencyThreshold {
+		check.Warning = "high latency slows down syncing many small files"
+	}
+
+	return check
+}
+
+func (r *RemoteDevelopment) checkClockSkew() DoctorCheck {
+	check := DoctorCheck{Name: "clock skew"}
+
+	skew, err := r.MeasureClockSkew()
+	if err != nil {
+		check.Err = err
+		return check
+	}
+
+	check.Result = skew.Round(time.Second).String()
+	if skew.Abs() > clockSkewThreshold {
+		check.Warning = "the remote clock is off, mutagen may keep re-syncing unchanged files and report odd modification times"
+	}
+
+	return check
+}
+
+// MeasureClockSkew compares the remote clock to the local one at the middle of the round-trip,
+// positive when the remote clock is ahead. The resolution is one second.
+func (r *RemoteDevelopment) MeasureClockSkew() (time.Duration, error) {
+	start := time.Now()
+	output, err := r.runRemoteCommand("date +%s")
+	roundTrip := time.Since(start)
+	if err != nil {
+		return 0, fmt.Errorf("cannot read the remote clock: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+
+	remoteSeconds, err := strconv.ParseInt(strings.TrimSpace(string(output)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("cannot parse the remote clock %q: %w", strings.TrimSpace(string(output)), err)
+	}
+
+	localTime := start.Add(roundTrip / 2)
+
+	return time.Unix(remoteSeconds, 0).Sub(localTime), nil
+}
+
+// MeasureLatency times a round-trip to the remote endpoint, the SSH handshake excluded.
+// For docker endpoints it times a no-op "docker exec", process startup included.
+func (r *RemoteDevelopment) MeasureLatency() (time.Duration, error) {
+	if r.dockerEndpoint != nil {
+		start := time.Now()
+		if output, err := r.dockerEndpoint.runCommand(r.getRemoteShell(), "true"); err != nil {
+			return 0, fmt.Errorf("cannot measure latency: %w: %s", err, strings.TrimSpace(string(output)))
+		}
+
+		return time.Since(start), nil
+	}
+
+	auth, err := bunnyshellSSH.PrivateKeyFile(r.sshPrivateKeyPath)
+	if err != nil {
+		return 0, err
+	}
+
+	server := bunnyshellSSH.NewEndpoint(r.sshPortForwardOptions.Interface, r.sshPortForwardOptions.LocalPort)
+
+	latency, err := bunnyshellSSH.MeasureRoundTrip(server, auth)
+	if err != nil {
+		return 0, fmt.Errorf("cannot measure latency: %w", err)
+	}
+
+	return latency, nil
+}
+
+// logRemoteLatency is informative only, a failed measurement doesn't stop the session from starting
+func (r *RemoteDevelopment) logRemoteLatency() {
+	if !r.logLatency {
+		return
+	}
+
+	latency, err := r.MeasureLatency()
+	if err != nil {
+		fmt.Printf("WARNING: %s\n", err)
+		return
+	}
+
+	fmt.Printf("INFO: remote latency: %s\n", latency.Round(time.Millisecond))
+}
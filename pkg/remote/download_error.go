@@ -0,0 +1,65 @@
+package remote
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DownloadAttempt is one try of a mutagen download, StatusCode is 0 when no response was received
+type DownloadAttempt struct {
+	StatusCode int
+	Err        error
+	Duration   time.Duration
+}
+
+// DownloadError is returned once all download attempts failed, carrying the history for diagnostics
+type DownloadError struct {
+	URL      string
+	Attempts []DownloadAttempt
+	Elapsed  time.Duration
+}
+
+func (e *DownloadError) Error() string {
+	attempts := []string{}
+	for index, attempt := range e.Attempts {
+		status := ""
+		if attempt.StatusCode != 0 {
+			status = fmt.Sprintf(" (status %d)", attempt.StatusCode)
+		}
+
+		attempts = append(attempts, fmt.Sprintf("#%d after %s%s: %s", index+1, attempt.Duration.Round(time.Millisecond), status, attempt.Err))
+	}
+
+	return fmt.Sprintf(
+		"cannot download mutagen from %s, %d attempts in %s: %s, please re-run the command",
+		e.URL,
+		len(e.Attempts),
+		e.Elapsed.Round(time.Millisecond),
+		strings.Join(attempts, "; "),
+	)
+}
+
+func (e *DownloadError) Unwrap() []error {
+	errs := []error{}
+	for _, attempt := range e.Attempts {
+		errs = append(errs, attempt.Err)
+	}
+
+	return errs
+}
+
+// downloadStatusError is an unexpected HTTP status, client errors are not worth retrying
+type downloadStatusError struct {
+	statusCode int
+	message    string
+}
+
+func (e *downloadStatusError) Error() string {
+	return e.message
+}
+
+func (e *downloadStatusError) isPermanent() bool {
+	return e.statusCode >= http.StatusBadRequest && e.statusCode < http.StatusInternalServerError
+}
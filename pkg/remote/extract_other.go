@@ -0,0 +1,15 @@
+//go:build !windows
+// +build !windows
+
+package remote
+
+import (
+	"errors"
+
+	"golang.org/x/sys/unix"
+)
+
+// isTransientExtractError matches a destination briefly held by another process, e.g. a previous binary still running
+func isTransientExtractError(err error) bool {
+	return errors.Is(err, unix.EBUSY) || errors.Is(err, unix.ETXTBSY)
+}
@@ -0,0 +1,14 @@
+package remote
+
+import (
+	"errors"
+
+	"golang.org/x/sys/windows"
+)
+
+// isTransientExtractError matches a destination locked by another process, typically an antivirus scanning it
+func isTransientExtractError(err error) bool {
+	return errors.Is(err, windows.ERROR_SHARING_VIOLATION) ||
+		errors.Is(err, windows.ERROR_LOCK_VIOLATION) ||
+		errors.Is(err, windows.ERROR_ACCESS_DENIED)
+}
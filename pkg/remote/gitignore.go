@@ -0,0 +1,112 @@
+package remote
+
+import (
+	"bufio"
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	gitignoreFilename = ".gitignore"
+)
+
+// getGitignorePaths translates the .gitignore files of root, nested ones included, into mutagen ignores.
+// Mutagen's ignore syntax follows git for negation ("!"), directory-only ("dir/"), anchoring ("/dir") and "**".
+// Not supported: escaped "\#", "\!" and trailing spaces, .git/info/exclude and the global excludes file.
+// As in git, a negation cannot re-include a file whose parent directory is ignored.
+func getGitignorePaths(root string) ([]string, error) {
+	ignores := []string{}
+
+	err := filepath.WalkDir(root, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !entry.IsDir() {
+			return nil
+		}
+
+		relativePath, err := filepath.Rel(root, filePath)
+		if err != nil {
+			return err
+		}
+		relativePath = filepath.ToSlash(relativePath)
+
+		// skipping ignored trees spares walking e.g. node_modules, the match is approximate
+		if entry.Name() == ".git" || (relativePath != "." && isIgnoredPath(relativePath, ignores)) {
+			return filepath.SkipDir
+		}
+
+		patterns, err := readGitignore(filepath.Join(filePath, gitignoreFilename))
+		if err != nil {
+			return err
+		}
+
+		for _, pattern := range patterns {
+			ignores = append(ignores, translateGitignorePattern(relativePath, pattern))
+		}
+
+		return nil
+	})
+
+	return ignores, err
+}
+
+func readGitignore(gitignorePath string) ([]string, error) {
+	file, err := os.Open(gitignorePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	patterns := []string{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		patterns = append(patterns, line)
+	}
+
+	return patterns, scanner.Err()
+}
+
+// translateGitignorePattern rebases a pattern of the .gitignore found in dir onto the sync root
+func translateGitignorePattern(dir, pattern string) string {
+	negation := ""
+	if strings.HasPrefix(pattern, "!") {
+		negation = "!"
+		pattern = strings.TrimPrefix(pattern, "!")
+	}
+
+	if dir == "." {
+		return negation + pattern
+	}
+
+	// a slash other than a trailing one anchors the pattern to the directory of the .gitignore
+	anchored := strings.Contains(strings.TrimSuffix(pattern, "/"), "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+
+	if anchored {
+		return negation + "/" + path.Join(dir, pattern) + trailingSlash(pattern)
+	}
+
+	return negation + "/" + dir + "/**/" + pattern
+}
+
+func trailingSlash(pattern string) string {
+	if strings.HasSuffix(pattern, "/") {
+		return "/"
+	}
+
+	return ""
+}
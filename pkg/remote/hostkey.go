@@ -0,0 +1,95 @@
+package remote
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	bunnyshellSSH "bunnyshell.com/dev/pkg/ssh"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+const (
+	knownHostsFilename = "known_hosts"
+
+	hostKeyFingerprintPrefix = "SHA256:"
+)
+
+var (
+	ErrHostKeyMismatch           = fmt.Errorf("remote host key does not match the pinned fingerprint")
+	ErrHostKeyVerificationFailed = fmt.Errorf("remote host key verification failed")
+	ErrSSHAuthFailed             = fmt.Errorf("remote SSH authentication failed")
+
+	// sshHostKeyFailures and sshAuthFailures match the OpenSSH client messages relayed by mutagen
+	sshHostKeyFailures = []string{"host key verification failed", "remote host identification has changed"}
+	sshAuthFailures    = []string{"permission denied (publickey"}
+)
+
+// ensurePinnedHostKey checks the host key against the pinned fingerprint, then trusts it through a known_hosts file
+// dedicated to the session, so mutagen's SSH transport verifies the very same key without prompting
+func (r *RemoteDevelopment) ensurePinnedHostKey(options *SSHOptions) (*SSHOptions, error) {
+	if options.HostKeyFingerprint == "" {
+		return options, nil
+	}
+
+	server := bunnyshellSSH.NewEndpoint(r.sshPortForwardOptions.Interface, r.sshPortForwardOptions.LocalPort)
+	hostKey, err := bunnyshellSSH.FetchHostKey(server)
+	if err != nil {
+		return nil, err
+	}
+
+	fingerprint := ssh.FingerprintSHA256(hostKey)
+	if fingerprint != options.HostKeyFingerprint {
+		return nil, fmt.Errorf("%w: got %s, expected %s", ErrHostKeyMismatch, fingerprint, options.HostKeyFingerprint)
+	}
+
+	knownHostsFilePath, err := r.getKnownHostsFilePath()
+	if err != nil {
+		return nil, err
+	}
+
+	address := net.JoinHostPort(r.sshPortForwardOptions.Interface, strconv.Itoa(r.sshPortForwardOptions.LocalPort))
+	line := knownhosts.Line([]string{knownhosts.Normalize(address)}, hostKey)
+	if err := os.WriteFile(knownHostsFilePath, []byte(line+"\n"), 0600); err != nil {
+		return nil, err
+	}
+
+	pinnedOptions := *options
+	pinnedOptions.StrictHostKeyChecking = "yes"
+	pinnedOptions.UserKnownHostsFile = knownHostsFilePath
+
+	return &pinnedOptions, nil
+}
+
+func (r *RemoteDevelopment) getKnownHostsFilePath() (string, error) {
+	deploymentDir, err := r.getDeploymentWorkspaceDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(deploymentDir, knownHostsFilename), nil
+}
+
+// classifySSHFailure tells host key failures from authentication failures, other errors are returned unchanged
+func classifySSHFailure(err error, output []byte) error {
+	message := strings.ToLower(string(output))
+
+	for _, failure := range sshHostKeyFailures {
+		if strings.Contains(message, failure) {
+			return fmt.Errorf("%w: check the known_hosts file or the pinned fingerprint: %w", ErrHostKeyVerificationFailed, err)
+		}
+	}
+
+	for _, failure := range sshAuthFailures {
+		if strings.Contains(message, failure) {
+			return fmt.Errorf("%w: %w", ErrSSHAuthFailed, err)
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,23 @@
+package remote
+
+import (
+	"fmt"
+)
+
+// finishInitialScan recreates the session without the initial scan ignores once the initial sync completed.
+// Mutagen has no notion of scan phases and bakes the ignores in the session at creation, hence the two sessions.
+// Limitations:
+//   - the new session starts without history: in two-way-safe mode, paths changed on both sides while ignored
+//     become conflicts, in two-way-resolved mode the alpha side wins
+//   - changes made during the recreation are picked up by the first scan of the new session, not lost
+//   - the ignored paths are synced in the background, WaitForSync doesn't wait for them
+func (r *RemoteDevelopment) finishInitialScan() error {
+	if !r.initialScanPhase {
+		return nil
+	}
+	r.initialScanPhase = false
+
+	fmt.Printf("INFO: initial sync completed, syncing the %d deferred paths\n", len(r.initialScanIgnores))
+
+	return r.RestartSession()
+}
@@ -117,7 +117,7 @@ func (r *RemoteDevelopment) getResourcePatch() (patch.Resource, error) {
 }
 
 func (r *RemoteDevelopment) prepareResource() error {
-	r.StartSpinner(" Setup k8s pod for remote development")
+	r.startStep(StepSetupPod)
 	defer r.StopSpinner()
 
 	currentManifestSnapshot, err := r.getCurrentManifestSnapshot()
@@ -595,8 +595,8 @@ func (r *RemoteDevelopment) getPVCName() (string, error) {
 }
 
 func (r *RemoteDevelopment) ensureSecret() error {
-	r.StartSpinner(" Setup k8s secret")
-	defer r.spinner.Stop()
+	r.startStep(StepSetupSecret)
+	defer r.StopSpinner()
 
 	sshPublicKeyData, err := os.ReadFile(r.sshPublicKeyPath)
 	if err != nil {
@@ -648,7 +648,7 @@ func (r *RemoteDevelopment) getResourceSelector() (*apiMetaV1.LabelSelector, err
 }
 
 func (r *RemoteDevelopment) waitPodReady() error {
-	r.StartSpinner(" Waiting for pod to be ready")
+	r.startStep(StepWaitPodReady)
 	defer r.StopSpinner()
 
 	resource, err := r.getResource()
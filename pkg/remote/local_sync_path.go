@@ -0,0 +1,51 @@
+package remote
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	mutagenConfig "bunnyshell.com/dev/pkg/mutagen/config"
+)
+
+var (
+	ErrLocalSyncPathNotSet = fmt.Errorf("no local sync path set")
+)
+
+// getResolvedLocalSyncPath returns the absolute local sync path, the working directory when unset and allowed
+func (r *RemoteDevelopment) getResolvedLocalSyncPath() (string, error) {
+	if r.localSyncPath != "" {
+		return filepath.Abs(r.localSyncPath)
+	}
+
+	if !r.syncWorkingDirByDefault {
+		return "", fmt.Errorf("%w, mutagen would be left to pick the directory to sync", ErrLocalSyncPathNotSet)
+	}
+
+	workingDir, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("cannot resolve the working directory to sync: %w", err)
+	}
+
+	return workingDir, nil
+}
+
+// resolveLocalSyncPath settles the local sync path before anything depends on it, e.g. the ignores or the state
+func (r *RemoteDevelopment) resolveLocalSyncPath() error {
+	if r.syncMode == mutagenConfig.None {
+		return nil
+	}
+
+	localSyncPath, err := r.getResolvedLocalSyncPath()
+	if err != nil {
+		return err
+	}
+
+	if r.localSyncPath == "" {
+		fmt.Printf("INFO: no local sync path set, syncing the working directory %s\n", localSyncPath)
+	}
+
+	r.localSyncPath = localSyncPath
+
+	return nil
+}
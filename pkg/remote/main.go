@@ -1,6 +1,7 @@
 package remote
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/signal"
@@ -9,6 +10,10 @@ import (
 )
 
 func (r *RemoteDevelopment) CanUp() error {
+	if r.dockerEndpoint != nil {
+		return nil
+	}
+
     resource, err := r.getResource()
    	if err != nil {
    		return err
@@ -25,14 +30,57 @@ func (r *RemoteDevelopment) CanUp() error {
 }
 
 func (r *RemoteDevelopment) Up() error {
-	if err := r.ensureSSHKeys(); err != nil {
+	if err := r.resolveLocalSyncPath(); err != nil {
 		return err
 	}
 
+	if r.dockerEndpoint == nil {
+		if err := r.ensureSSHKeys(); err != nil {
+			return err
+		}
+	}
+
 	if err := r.ensureMutagen(); err != nil {
 		return err
 	}
 
+	// a docker endpoint is reached through the docker CLI, the cluster is left untouched
+	if r.dockerEndpoint == nil {
+		if err := r.ensureRemoteResource(); err != nil {
+			return err
+		}
+	}
+
+	if err := r.ensureRemoteSyncPath(); err != nil {
+		return err
+	}
+
+	if err := r.verifyRemoteSyncPathWritable(); err != nil {
+		return err
+	}
+
+	if err := r.inspectRemoteSyncPath(); err != nil {
+		return err
+	}
+
+	if err := r.verifyNoCaseConflicts(); err != nil {
+		return err
+	}
+
+	r.logRemoteLatency()
+	r.recordRemoteIdentity()
+
+	if err := r.startMutagenSession(); err != nil {
+		return err
+	}
+
+	r.startSyncDebounce()
+
+	return r.saveSessionState()
+}
+
+// ensureRemoteResource prepares the cluster resource and reaches its pod over SSH
+func (r *RemoteDevelopment) ensureRemoteResource() error {
 	if err := r.ensureSecret(); err != nil {
 		return err
 	}
@@ -57,19 +105,28 @@ func (r *RemoteDevelopment) Up() error {
 		return err
 	}
 
-	if err := r.startSSHTunnels(); err != nil {
-		return err
-	}
-
-	return r.startMutagenSession()
+	return r.startSSHTunnels()
 }
 
 func (r *RemoteDevelopment) Down() error {
-	if err := r.restoreDeployment(); err != nil {
-		return err
+	if r.dockerEndpoint == nil {
+		if err := r.restoreDeployment(); err != nil {
+			return err
+		}
+
+		if err := r.deletePVC(); err != nil {
+			return err
+		}
+	}
+
+	// terminate the session left behind by a previous invocation, if any
+	if err := r.AttachExistingSession(); err == nil {
+		r.flushBeforeTerminate()
+		r.terminateMutagenSession()
+		r.removeSessionState()
 	}
 
-	if err := r.deletePVC(); err != nil {
+	if err := r.removeDeploymentWorkspaceDir(); err != nil {
 		return err
 	}
 
@@ -91,8 +148,33 @@ func (r *RemoteDevelopment) Wait() error {
 	}
 }
 
+// Run brings the remote development up, waits for the initial sync and blocks until ctx is cancelled
+// or Close is called, then tears it down
+func (r *RemoteDevelopment) Run(ctx context.Context) error {
+	if err := r.Up(); err != nil {
+		r.Close()
+		return err
+	}
+	defer r.Close()
+
+	if err := r.WaitForSync(ctx); err != nil && ctx.Err() == nil {
+		return err
+	}
+
+	select {
+	case <-ctx.Done():
+	case <-r.stopChannel:
+	}
+
+	return nil
+}
+
 func (r *RemoteDevelopment) Close() {
-	r.terminateMutagenSession()
+	if !r.reuseSession {
+		r.flushBeforeTerminate()
+		r.terminateMutagenSession()
+		r.removeSessionState()
+	}
 
 	// close ssh tunnels
 	for i := range r.sshTunnels {
@@ -104,8 +186,16 @@ func (r *RemoteDevelopment) Close() {
 		r.sshPortForwarder.Close()
 	}
 
+	r.stopDebugDaemon()
+
+	if r.syncLog != nil {
+		r.syncLog.close()
+	}
+
 	// close cli command
 	if r.stopChannel != nil {
-		close(r.stopChannel)
+		r.stopOnce.Do(func() {
+			close(r.stopChannel)
+		})
 	}
 }
@@ -0,0 +1,83 @@
+package remote
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Manager coordinates several remote development sessions sharing the same mutagen binary and daemon.
+// The daemon is stopped only once the last managed session ends.
+type Manager struct {
+	mutex sync.Mutex
+
+	sessions map[string]*RemoteDevelopment
+}
+
+func NewManager() *Manager {
+	return &Manager{
+		sessions: make(map[string]*RemoteDevelopment),
+	}
+}
+
+func (m *Manager) Start(remoteDevelopment *RemoteDevelopment) error {
+	identity, err := remoteDevelopment.getSessionStateIdentity()
+	if err != nil {
+		return err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if _, ok := m.sessions[identity]; ok {
+		return fmt.Errorf("remote development already started for %s", identity)
+	}
+
+	if err := remoteDevelopment.Up(); err != nil {
+		return err
+	}
+
+	m.sessions[identity] = remoteDevelopment
+
+	return nil
+}
+
+func (m *Manager) Stop(remoteDevelopment *RemoteDevelopment) error {
+	identity, err := remoteDevelopment.getSessionStateIdentity()
+	if err != nil {
+		return err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.stop(identity)
+}
+
+func (m *Manager) StopAll() error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for identity := range m.sessions {
+		if err := m.stop(identity); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *Manager) stop(identity string) error {
+	remoteDevelopment, ok := m.sessions[identity]
+	if !ok {
+		return fmt.Errorf("remote development not started for %s", identity)
+	}
+
+	remoteDevelopment.Close()
+	delete(m.sessions, identity)
+
+	if len(m.sessions) > 0 {
+		return nil
+	}
+
+	return remoteDevelopment.terminateMutagenDaemon()
+}
@@ -0,0 +1,46 @@
+package remote
+
+import (
+	"path/filepath"
+	"strings"
+
+	"bunnyshell.com/dev/pkg/util"
+)
+
+// mutagenIgnoreEscaper escapes the pattern characters of mutagen's ignore syntax, for literal paths
+var mutagenIgnoreEscaper = strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`, `[`, `\[`, `]`, `\]`, `{`, `\{`, `}`, `\}`, `!`, `\!`)
+
+// getMetadataIgnores ignores the tool-managed paths which reside under the sync root: the metadata dir,
+// the workspace, the config file, the sync log and the temp dir. Paths outside of the sync root are skipped.
+func (r *RemoteDevelopment) getMetadataIgnores() []string {
+	candidates := []string{r.configFilePath, r.tempDir}
+	if r.metadataDir != "" {
+		metadataDir := r.metadataDir
+		if !filepath.IsAbs(metadataDir) {
+			metadataDir = filepath.Join(r.localSyncPath, metadataDir)
+		}
+		candidates = append(candidates, metadataDir)
+	}
+	if workspaceDir, err := util.GetRemoteDevWorkspaceDirPath(); err == nil {
+		candidates = append(candidates, workspaceDir)
+	}
+	if r.syncLog != nil {
+		candidates = append(candidates, r.syncLog.path, r.syncLog.path+".1")
+	}
+
+	ignores := []string{}
+	for _, candidate := range candidates {
+		if candidate == "" {
+			continue
+		}
+
+		relativePath, err := filepath.Rel(r.localSyncPath, candidate)
+		if err != nil || relativePath == "." || relativePath == ".." || strings.HasPrefix(relativePath, ".."+string(filepath.Separator)) {
+			continue
+		}
+
+		ignores = append(ignores, "/"+mutagenIgnoreEscaper.Replace(filepath.ToSlash(relativePath)))
+	}
+
+	return ignores
+}
@@ -1,36 +1,63 @@
 package remote
 
 import (
-	"archive/tar"
 	"bufio"
-	"compress/gzip"
 	"crypto/md5"
 	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
-	"net"
-	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
-	"runtime"
+	"regexp"
+	"slices"
+	"sort"
 	"strings"
 	"time"
 
 	"bunnyshell.com/dev/pkg/build"
 	mutagenConfig "bunnyshell.com/dev/pkg/mutagen/config"
 	"bunnyshell.com/dev/pkg/util"
-	"gopkg.in/yaml.v3"
+	"golang.org/x/mod/semver"
 )
 
 const (
 	mutagenBinFilename      = "mutagen"
 	mutagenDownloadFilename = "mutagen_%s_%s_%s.tar.gz"
-	mutagenDownloadUrl      = "https://github.com/mutagen-io/mutagen/releases/download/%s/%s"
+	mutagenDownloadUrl      = "%s/%s/%s"
+	mutagenDownloadAttempts = 2
+	mutagenBinMinSize       = 5 << 20
 
-	mutagenConfigFilenamePattern = "mutagen.%s.yaml"
-	mutagenIgnoreFilename        = ".rdignore"
+	defaultCreateAttempts     = 3
+	defaultCreateRetryBackoff = 2 * time.Second
+
+	maxNiceness = 19
+
+	mutagenConfigFilename = "mutagen.yaml"
+	mutagenIgnoreFilename = ".rdignore"
+
+	mutagenSessionNameMaxLength = 63
+	mutagenSessionKeyLength     = 16
+	defaultSessionNamePrefix    = "rd-"
+
+	mutagenLabelSessionKey = "remote-dev.bunnyshell.com/session-key"
+)
+
+var (
+	ErrInvalidSessionName = fmt.Errorf("invalid mutagen session name")
+	ErrSessionNotOwned    = fmt.Errorf("a mutagen session with the same name exists but belongs to another deployment")
+
+	mutagenSessionNameExp = regexp.MustCompile("^[a-zA-Z][a-zA-Z0-9_-]*$")
+
+	mutagenTransientCreateFailures = []string{
+		"connection refused",
+		"connection reset",
+		"connection closed",
+		"timed out",
+		"no route to host",
+		"kex_exchange_identification",
+	}
 )
 
 func (r *RemoteDevelopment) ensureMutagen() error {
@@ -38,43 +65,245 @@ func (r *RemoteDevelopment) ensureMutagen() error {
 		return nil
 	}
 
-	r.StartSpinner(" Setup Mutagen")
+	r.startStep(StepSetupMutagen)
 	defer r.StopSpinner()
 
-	if err := ensureMutagenBin(); err != nil {
+	if err := r.ensureMutagenBin(); err != nil {
 		return err
 	}
 
-	return r.ensureMutagenConfigFile()
+	if r.debugDaemonEnabled {
+		if err := r.startDebugDaemon(); err != nil {
+			return err
+		}
+	} else if err := r.ensureMutagenDaemonCompatible(); err != nil {
+		return err
+	}
+
+	removeLegacyMutagenConfigFiles()
+
+	if err := r.ensureMutagenConfigFile(); err != nil {
+		return err
+	}
+
+	r.mutagenSetup.ConfigWritten = true
+
+	return nil
+}
+
+// MutagenSetup describes how the mutagen binary was provisioned by Up
+type MutagenSetup struct {
+	Downloaded    bool
+	Version       string
+	ConfigWritten bool
+	BinPath       string
+}
+
+func (r *RemoteDevelopment) MutagenSetup() MutagenSetup {
+	return r.mutagenSetup
 }
 
 func (r *RemoteDevelopment) ensureMutagenConfigFile() error {
+	_, err := r.WriteConfig()
+	return err
+}
+
+// WriteConfig generates the mutagen config of the session from the current settings, without touching the binary.
+// It returns the path of the written file.
+func (r *RemoteDevelopment) WriteConfig() (string, error) {
 	mutagenConfigFilePath, err := r.getMutagenConfigFilePath()
 	if err != nil {
-		return err
+		return "", err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(mutagenConfigFilePath), 0755); err != nil {
+		return "", err
+	}
+
+	config, err := r.getMutagenConfig()
+	if err != nil {
+		return "", err
+	}
+
+	// an equivalent file is left untouched, keeping its modification time meaningful
+	upToDate, err := isMutagenConfigUpToDate(mutagenConfigFilePath, config)
+	if err != nil {
+		return "", err
+	}
+
+	if !upToDate {
+		data, err := config.Marshal()
+		if err != nil {
+			return "", err
+		}
+
+		if err := os.WriteFile(mutagenConfigFilePath, data, r.configFileMode); err != nil {
+			return "", err
+		}
+	}
+
+	// WriteFile keeps the mode of an existing file and is subject to the umask
+	if err := os.Chmod(mutagenConfigFilePath, r.configFileMode); err != nil {
+		return "", err
+	}
+
+	return mutagenConfigFilePath, nil
+}
+
+func (r *RemoteDevelopment) getMutagenConfig() (*mutagenConfig.Configuration, error) {
+	ignore, err := r.getMutagenIgnore()
+	if err != nil {
+		return nil, err
+	}
+
+	return r.newMutagenConfig(ignore), nil
+}
+
+func (r *RemoteDevelopment) newMutagenConfig(ignore *mutagenConfig.Ignore) *mutagenConfig.Configuration {
+	defaults := mutagenConfig.NewSyncDefaults().WithMode(r.syncMode).WithScanMode(r.scanMode).WithIgnore(ignore).
+		WithWatch(r.getMutagenWatch())
+	sync := mutagenConfig.NewSync().WithDefaults(defaults)
+
+	return mutagenConfig.NewConfiguration().WithSync(sync)
+}
+
+// isMutagenConfigUpToDate compares semantically, a missing or unparsable file is outdated
+func isMutagenConfigUpToDate(mutagenConfigFilePath string, config *mutagenConfig.Configuration) (bool, error) {
+	data, err := os.ReadFile(mutagenConfigFilePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	matches, err := config.Matches(data)
+	if err != nil {
+		return false, nil
+	}
+
+	return matches, nil
+}
+
+func (r *RemoteDevelopment) getMutagenIgnore() (*mutagenConfig.Ignore, error) {
+	if r.localSyncFile != "" {
+		return r.getSingleFileMutagenIgnore(), nil
 	}
 
-	enableVCS := true
 	sessionIgnores, err := r.getMutagenSessionIgnores()
 	if sessionIgnores == nil {
-		r.StopSpinner()
-		fmt.Printf("INFO: All files will be synchronized. You can exclude files from sync by creating a %s/%s file.\n", r.localSyncPath, mutagenIgnoreFilename)
-		r.StartSpinner("")
+		r.pauseSpinner(func() {
+			fmt.Printf("INFO: All files will be synchronized. You can exclude files from sync by creating a %s/%s file.\n", r.localSyncPath, mutagenIgnoreFilename)
+		})
 	}
 	if err != nil {
-		return err
+		return nil, err
 	}
-	ignore := mutagenConfig.NewIgnore().WithVCS(&enableVCS).WithPaths(sessionIgnores)
-	defaults := mutagenConfig.NewSyncDefaults().WithMode(r.syncMode).WithIgnore(ignore)
-	sync := mutagenConfig.NewSync().WithDefaults(defaults)
-	config := mutagenConfig.NewConfiguration().WithSync(sync)
 
-	data, err := yaml.Marshal(config)
+	return r.buildMutagenIgnore(sessionIgnores, true)
+}
+
+// getSettingsMutagenIgnore is getMutagenIgnore without notices and without the ignores resolved from the local tree
+func (r *RemoteDevelopment) getSettingsMutagenIgnore() (*mutagenConfig.Ignore, error) {
+	if r.localSyncFile != "" {
+		return r.getSingleFileMutagenIgnore(), nil
+	}
+
+	sessionIgnores, err := r.getMutagenSessionIgnores()
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	return r.buildMutagenIgnore(sessionIgnores, false)
+}
+
+func (r *RemoteDevelopment) getSingleFileMutagenIgnore() *mutagenConfig.Ignore {
+	enableVCS := r.ignoreGroups[mutagenConfig.IgnoreGroupVCS]
+
+	return mutagenConfig.NewIgnore().WithVCS(&enableVCS).WithPaths(getSingleFileIgnores(r.localSyncFile))
+}
+
+// buildMutagenIgnore composes the ignores in precedence order, resolveTree walks the local tree
+// for the .gitignore patterns and the stale files
+func (r *RemoteDevelopment) buildMutagenIgnore(sessionIgnores []string, resolveTree bool) (*mutagenConfig.Ignore, error) {
+	enableVCS := r.ignoreGroups[mutagenConfig.IgnoreGroupVCS]
+
+	ignore := mutagenConfig.NewIgnore().WithVCS(&enableVCS)
+	for _, group := range mutagenConfig.IgnoreGroupOrder {
+		if !r.ignoreGroups[group] {
+			continue
+		}
+
+		switch group {
+		case mutagenConfig.IgnoreGroupLargeBinaries:
+			ignore.WithLargeBinaries(r.largeBinaryExtensions...)
+		case mutagenConfig.IgnoreGroupCustom:
+			ignore.WithPaths(r.customIgnores)
+		default:
+			ignore.WithPaths(mutagenConfig.IgnoreGroupPaths[group])
+		}
+	}
+	if r.useGitignore && resolveTree {
+		gitignorePaths, err := getGitignorePaths(r.localSyncPath)
+		if err != nil {
+			return nil, err
+		}
+
+		ignore.WithPaths(gitignorePaths)
+	}
+	// the session ignores come last so they can override the groups and the .gitignore patterns
+	ignore.WithPaths(sessionIgnores)
+	if r.initialScanPhase {
+		ignore.WithPaths(r.initialScanIgnores)
+	}
+	if r.ignoreOlderThan > 0 && resolveTree {
+		staleFiles, err := getStaleFileIgnores(r.localSyncPath, ignore.Paths, r.ignoreOlderThan)
+		if err != nil {
+			return nil, err
+		}
+
+		truncated := len(staleFiles) > maxStaleFileIgnores
+		if truncated {
+			staleFiles = staleFiles[:maxStaleFileIgnores]
+		}
+
+		if !slices.Equal(staleFiles, r.staleFiles) {
+			r.pauseSpinner(func() {
+				fmt.Printf("INFO: %d paths not modified in the last %s are excluded from sync\n", len(staleFiles), r.ignoreOlderThan)
+				if truncated {
+					fmt.Printf("WARNING: more than %d stale paths, the remaining ones are synchronized\n", maxStaleFileIgnores)
+				}
+			})
+		}
+		r.staleFiles = staleFiles
+
+		ignore.WithPaths(staleFiles)
+	}
+	// last, so no negated pattern brings the tool's own files back
+	ignore.WithPaths(r.getMetadataIgnores())
+
+	return ignore.Deduplicate(), nil
+}
+
+// newDefaultIgnoreGroups matches the behaviour predating the groups: mutagen's VCS ignores and the caller's paths
+func newDefaultIgnoreGroups() map[mutagenConfig.IgnoreGroup]bool {
+	return map[mutagenConfig.IgnoreGroup]bool{
+		mutagenConfig.IgnoreGroupVCS:    true,
+		mutagenConfig.IgnoreGroupCustom: true,
+	}
+}
+
+func getSingleFileIgnores(filename string) []string {
+	return []string{"*", "!/" + filename}
+}
+
+// SingleFileIgnores returns the ignores applied by WithLocalSyncFile, nil when the whole directory is synced
+func (r *RemoteDevelopment) SingleFileIgnores() []string {
+	if r.localSyncFile == "" {
+		return nil
 	}
 
-	return os.WriteFile(mutagenConfigFilePath, data, 0644)
+	return getSingleFileIgnores(r.localSyncFile)
 }
 
 func (r *RemoteDevelopment) startMutagenSession() error {
@@ -82,48 +311,223 @@ func (r *RemoteDevelopment) startMutagenSession() error {
 		return nil
 	}
 
-	r.StartSpinner(" Start Mutagen Session")
+	r.startStep(StepStartMutagenSession)
 	defer r.StopSpinner()
 
-	mutagenBinPath, err := getMutagenBinPath()
+	startTime := time.Now()
+	r.sessionStartedAt = startTime
+
+	if r.reuseSession {
+		reused, err := r.reuseMutagenSession()
+		if err != nil {
+			return err
+		}
+
+		if reused {
+			return r.recordSessionStartup(true, time.Since(startTime))
+		}
+	}
+
+	if err := r.createMutagenSession(); err != nil {
+		return err
+	}
+
+	return r.recordSessionStartup(false, time.Since(startTime))
+}
+
+func (r *RemoteDevelopment) createMutagenSession() error {
+	remoteEndpoint, err := r.getMutagenRemoteEndpoint()
 	if err != nil {
 		return err
 	}
-	mutagenConfigFilePath, err := r.getMutagenConfigFilePath()
+	sessionName, err := r.getMutagenSessionName()
 	if err != nil {
 		return err
 	}
 
-	hostname, err := r.getSSHHostname()
+	existingSession, err := r.getMutagenSession()
 	if err != nil {
 		return err
 	}
-	sessionName, err := r.getMutagenSessionName()
+	if existingSession != nil {
+		if err := r.ensureMutagenSessionOwnership(existingSession); err != nil {
+			return err
+		}
+
+		// a leftover of ours, e.g. from a crashed invocation
+		if err := r.terminateMutagenSession(); err != nil {
+			return err
+		}
+	}
+
+	// mutagen propagates alpha to beta in one-way modes and alpha wins conflicts in two-way-resolved mode
+	alpha, beta := r.localSyncPath, remoteEndpoint
+	if r.reverse {
+		alpha, beta = beta, alpha
+	}
+
+	if err := r.execMutagenSyncCreate(sessionName, alpha, beta, nil); err != nil {
+		return fmt.Errorf("cannot sync %s with %s: %w", r.localSyncPath, remoteEndpoint, err)
+	}
+
+	return nil
+}
+
+func (r *RemoteDevelopment) execMutagenSyncCreate(sessionName, alpha, beta string, labels map[string]string) error {
+	mutagenBinPath, err := r.getMutagenBinPath()
 	if err != nil {
 		return err
 	}
+	mutagenConfigFilePath, err := r.getMutagenConfigFilePath()
+	if err != nil {
+		return err
+	}
+	if err := validateExtraCreateArgs(r.extraCreateArgs); err != nil {
+		return err
+	}
+
+	sessionKey, err := r.getMutagenSessionKey()
+	if err != nil {
+		return err
+	}
+
 	mutagenArgs := []string{
 		"sync",
 		"create",
 		"-n", sessionName,
+		"-l", fmt.Sprintf("%s=%s", mutagenLabelSessionKey, sessionKey),
 		"--no-global-configuration",
 		"-c", mutagenConfigFilePath,
-		r.localSyncPath,
-		fmt.Sprintf(
-			"%s:%s",
-			hostname,
-			r.remoteSyncPath,
-		),
 	}
 
-	mutagenCmd := exec.Command(mutagenBinPath, mutagenArgs...)
+	if configHash, err := r.getDesiredConfigHash(); err == nil {
+		mutagenArgs = append(mutagenArgs, "-l", fmt.Sprintf("%s=%s", mutagenLabelConfigHash, configHash))
+	}
+
+	labelNames := make([]string, 0, len(labels))
+	for name := range labels {
+		labelNames = append(labelNames, name)
+	}
+	sort.Strings(labelNames)
+	for _, name := range labelNames {
+		mutagenArgs = append(mutagenArgs, "-l", fmt.Sprintf("%s=%s", name, labels[name]))
+	}
+
+	mutagenArgs = append(mutagenArgs, r.extraCreateArgs...)
+	mutagenArgs = append(mutagenArgs, alpha, beta)
+
+	// the SSH server of a freshly started pod may not accept connections yet
+	delay := r.createRetryBackoff
+	for attempt := 1; ; attempt++ {
+		mutagenCmd := r.newMutagenCommand(mutagenBinPath, mutagenArgs...)
+
+		output, err := mutagenCmd.CombinedOutput()
+		r.logSync("create attempt %d: %v: %s", attempt, err, output)
+		if err == nil {
+			return nil
+		}
+
+		if attempt < r.createAttempts && isTransientCreateFailure(output) {
+			time.Sleep(delay)
+			delay *= 2
+			continue
+		}
+
+		if mutagenCmd.ProcessState.ExitCode() != 0 {
+			fmt.Println(string(output))
+		}
 
-	output, err := mutagenCmd.CombinedOutput()
-	if mutagenCmd.ProcessState.ExitCode() != 0 {
-		fmt.Println(string(output))
+		return r.diagnoseMutagenAgentInstall(classifySSHFailure(err, output), output)
 	}
+}
 
-	return err
+// isTransientCreateFailure matches connection errors, configuration errors are never retried
+func isTransientCreateFailure(output []byte) bool {
+	message := strings.ToLower(string(output))
+	for _, failure := range mutagenTransientCreateFailures {
+		if strings.Contains(message, failure) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func validateExtraCreateArgs(extraCreateArgs []string) error {
+	for _, arg := range extraCreateArgs {
+		flag, _, _ := strings.Cut(arg, "=")
+
+		switch flag {
+		case "-n", "--name", "-l", "--label", "-c", "--configuration-file", "--no-global-configuration":
+			return fmt.Errorf("extra mutagen create argument %s conflicts with the arguments managed by %s", arg, build.Name)
+		}
+	}
+
+	return nil
+}
+
+// reuseMutagenSession attaches to a healthy session left running by a previous invocation,
+// an unhealthy one is terminated so it can be recreated
+func (r *RemoteDevelopment) reuseMutagenSession() (bool, error) {
+	session, err := r.getMutagenSession()
+	if err != nil {
+		return false, err
+	}
+
+	if session == nil {
+		return false, nil
+	}
+
+	if err := r.ensureMutagenSessionOwnership(session); err != nil {
+		return false, err
+	}
+
+	if !session.IsHealthy() {
+		return false, r.terminateMutagenSession()
+	}
+
+	recreate, err := r.shouldRecreateOnConfigDrift(true)
+	if err != nil || !recreate {
+		return err == nil, err
+	}
+
+	return false, r.terminateMutagenSession()
+}
+
+// ensureMutagenSessionOwnership refuses to operate on a same-named session created for another deployment or by hand
+func (r *RemoteDevelopment) ensureMutagenSessionOwnership(session *MutagenSession) error {
+	sessionKey, err := r.getMutagenSessionKey()
+	if err != nil {
+		return err
+	}
+
+	if session.Labels[mutagenLabelSessionKey] != sessionKey {
+		return fmt.Errorf("%w: \"%s\" (%s)", ErrSessionNotOwned, session.Name, session.Identifier)
+	}
+
+	return nil
+}
+
+func (r *RemoteDevelopment) recordSessionStartup(reused bool, duration time.Duration) error {
+	r.sessionStartup = SessionStartup{
+		Reused:   reused,
+		Duration: duration,
+	}
+
+	if !reused {
+		return nil
+	}
+
+	state, err := r.getSessionState()
+	if err != nil {
+		return err
+	}
+
+	if state != nil && state.CreateDuration > duration {
+		r.sessionStartup.TimeSaved = state.CreateDuration - duration
+	}
+
+	return nil
 }
 
 func (r *RemoteDevelopment) getMutagenSessionIgnores() ([]string, error) {
@@ -151,8 +555,50 @@ func (r *RemoteDevelopment) getMutagenSessionIgnores() ([]string, error) {
 	return ignores, nil
 }
 
+// newMutagenCommand runs mutagen with the extra environment, e.g. the agent socket used by its SSH transport,
+// and the priority, applied to every command as the daemon inherits it from the command starting it
+func (r *RemoteDevelopment) newMutagenCommand(mutagenBinPath string, args ...string) *exec.Cmd {
+	r.logSync("exec: mutagen %s", strings.Join(args, " "))
+
+	name, args := r.withPriority(mutagenBinPath, args)
+
+	mutagenCmd := exec.Command(name, args...)
+	mutagenCmd.Env = r.getMutagenCommandEnv()
+
+	return mutagenCmd
+}
+
+// withPriority falls back to the normal priority with a warning where lowering it is not supported
+func (r *RemoteDevelopment) withPriority(name string, args []string) (string, []string) {
+	if r.priority == 0 {
+		return name, args
+	}
+
+	prefix, err := getPriorityCommandPrefix(r.priority)
+	if err != nil {
+		r.priorityWarningOnce.Do(func() {
+			fmt.Printf("WARNING: %s, running mutagen with the normal priority\n", err)
+		})
+
+		return name, args
+	}
+
+	return prefix[0], append(append(prefix[1:], name), args...)
+}
+
+// getMutagenCommandEnv merges the extra environment over the current one, nil inherits it unchanged
+func (r *RemoteDevelopment) getMutagenCommandEnv() []string {
+	if len(r.mutagenEnv) == 0 {
+		return nil
+	}
+
+	return append(os.Environ(), r.mutagenEnv...)
+}
+
+// terminateMutagenSession only stops syncing, "sync terminate" leaves the content of both endpoints untouched.
+// Deleting remote content must stay a separate, explicitly named opt-in, see WithPurgeRemoteOnAbort.
 func (r *RemoteDevelopment) terminateMutagenSession() error {
-	mutagenBinPath, err := getMutagenBinPath()
+	mutagenBinPath, err := r.getMutagenBinPath()
 	if err != nil {
 		return err
 	}
@@ -168,14 +614,41 @@ func (r *RemoteDevelopment) terminateMutagenSession() error {
 		sessionName,
 	}
 
-	mutagenCmd := exec.Command(mutagenBinPath, mutagenArgs...)
+	mutagenCmd := r.newMutagenCommand(mutagenBinPath, mutagenArgs...)
 	mutagenCmd.Run()
 
 	return nil
 }
 
+// resetMutagenSession discards the sync history without deleting content. The next cycle reconciles from scratch,
+// in one-way-replica mode that mirrors the local tree, as any cycle of that mode does.
+func (r *RemoteDevelopment) resetMutagenSession() error {
+	mutagenBinPath, err := r.getMutagenBinPath()
+	if err != nil {
+		return err
+	}
+
+	sessionName, err := r.getMutagenSessionName()
+	if err != nil {
+		return err
+	}
+
+	mutagenArgs := []string{
+		"sync",
+		"reset",
+		sessionName,
+	}
+
+	output, err := r.newMutagenCommand(mutagenBinPath, mutagenArgs...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("cannot reset mutagen session %s: %w: %s", sessionName, err, strings.TrimSpace(string(output)))
+	}
+
+	return nil
+}
+
 func (r *RemoteDevelopment) terminateMutagenDaemon() error {
-	mutagenBinPath, err := getMutagenBinPath()
+	mutagenBinPath, err := r.getMutagenBinPath()
 	if err != nil {
 		return err
 	}
@@ -185,33 +658,104 @@ func (r *RemoteDevelopment) terminateMutagenDaemon() error {
 		"stop",
 	}
 
-	mutagenCmd := exec.Command(mutagenBinPath, mutagenArgs...)
+	mutagenCmd := r.newMutagenCommand(mutagenBinPath, mutagenArgs...)
 	mutagenCmd.Run()
 
 	return nil
 }
 
 func (r *RemoteDevelopment) getMutagenSessionName() (string, error) {
-	sessionKey, err := r.getMutagenSessionKey()
-	if err != nil {
+	// an explicit WithSessionName, the persisted name of an attached session or the name resolved earlier
+	if r.sessionName != "" {
+		if err := validateMutagenSessionName(r.sessionName); err != nil {
+			return "", err
+		}
+
+		return r.sessionName, nil
+	}
+
+	sessionName := ""
+	if r.sessionNamer != nil {
+		sessionName = r.sessionNamer(r)
+	} else {
+		sessionKey, err := r.getMutagenSessionKey()
+		if err != nil {
+			return "", err
+		}
+
+		sessionName = r.sessionNamePrefix + sessionKey
+	}
+
+	if err := validateMutagenSessionName(sessionName); err != nil {
 		return "", err
 	}
 
-	return fmt.Sprintf("rd-%s", sessionKey), nil
+	r.sessionName = sessionName
+
+	return sessionName, nil
+}
+
+// validateMutagenSessionNamePrefix leaves room for the session key within the name length limit
+func validateMutagenSessionNamePrefix(prefix string) error {
+	if len(prefix)+mutagenSessionKeyLength > mutagenSessionNameMaxLength {
+		return fmt.Errorf("%w: prefix \"%s\" is longer than %d characters", ErrInvalidSessionName, prefix, mutagenSessionNameMaxLength-mutagenSessionKeyLength)
+	}
+
+	return validateMutagenSessionName(prefix)
 }
 
+func validateMutagenSessionName(sessionName string) error {
+	if len(sessionName) > mutagenSessionNameMaxLength {
+		return fmt.Errorf("%w: \"%s\" is longer than %d characters", ErrInvalidSessionName, sessionName, mutagenSessionNameMaxLength)
+	}
+
+	if !mutagenSessionNameExp.MatchString(sessionName) {
+		return fmt.Errorf("%w: \"%s\" must start with a letter and contain only letters, digits, '-' and '_'", ErrInvalidSessionName, sessionName)
+	}
+
+	return nil
+}
+
+// getMutagenSessionKey identifies the deployment and remote path, or the container and remote path
+// of a docker endpoint, which requires no cluster access
 func (r *RemoteDevelopment) getMutagenSessionKey() (string, error) {
+	if r.dockerEndpoint != nil {
+		return newMutagenSessionKey(r.remoteSyncPath, r.dockerEndpoint.Container, mutagenDockerScheme, r.sessionScope), nil
+	}
+
 	resource, err := r.getResource()
 	if err != nil {
 		return "", err
 	}
 
-	plaintext := fmt.Sprintf("%s-%s-%s", r.remoteSyncPath, resource.GetName(), resource.GetNamespace())
+	return newMutagenSessionKey(r.remoteSyncPath, resource.GetName(), resource.GetNamespace(), r.sessionScope), nil
+}
+
+func newMutagenSessionKey(remoteSyncPath, resourceName, namespace, sessionScope string) string {
+	plaintext := fmt.Sprintf("%s-%s-%s", remoteSyncPath, resourceName, namespace)
+	if sessionScope != "" {
+		plaintext = fmt.Sprintf("%s-%s", plaintext, sessionScope)
+	}
 	hash := md5.Sum([]byte(plaintext))
-	return hex.EncodeToString(hash[:])[:16], nil
+	return hex.EncodeToString(hash[:])[:mutagenSessionKeyLength]
 }
 
-func getMutagenBinPath() (string, error) {
+func (r *RemoteDevelopment) getMutagenBinPath() (string, error) {
+	if r.mutagenBinPath != "" {
+		return r.mutagenBinPath, nil
+	}
+
+	if r.preferSystemMutagen {
+		if systemMutagenBinPath, _, err := findSystemMutagenBin(); err == nil {
+			r.mutagenBinPath = systemMutagenBinPath
+			return systemMutagenBinPath, nil
+		}
+	}
+
+	return getWorkspaceMutagenBinPath()
+}
+
+func getWorkspaceMutagenBinPath() (string, error) {
 	workspaceDir, err := util.GetRemoteDevWorkspaceDir()
 	if err != nil {
 		return "", err
@@ -220,126 +764,193 @@ func getMutagenBinPath() (string, error) {
 	return filepath.Join(workspaceDir, getMutagenBinFilename()), nil
 }
 
+// getMutagenConfigFilePath is in the deployment workspace, derived from the session key, so each deployment
+// and remote path gets its own config file and sessions sharing the workspace never overwrite each other's config.
+// An explicit WithConfigFilePath takes precedence.
 func (r *RemoteDevelopment) getMutagenConfigFilePath() (string, error) {
-	workspaceDir, err := util.GetRemoteDevWorkspaceDir()
-	if err != nil {
-		return "", err
+	if r.configFilePath != "" {
+		return r.configFilePath, nil
 	}
 
-	sessionKey, err := r.getMutagenSessionKey()
+	deploymentDir, err := r.getDeploymentWorkspaceDir()
 	if err != nil {
 		return "", err
 	}
 
-	return filepath.Join(workspaceDir, fmt.Sprintf(mutagenConfigFilenamePattern, sessionKey)), nil
+	return filepath.Join(deploymentDir, mutagenConfigFilename), nil
 }
 
-func ensureMutagenBin() error {
-	mutagenBinPath, err := getMutagenBinPath()
+func (r *RemoteDevelopment) ensureMutagenBin() error {
+	provision, err := loadMutagenProvision()
 	if err != nil {
 		return err
 	}
+	r.mutagenProvision = provision
+
+	version := provision.Version
+	r.mutagenSetup = MutagenSetup{Version: version}
+
+	if r.preferSystemMutagen {
+		if systemMutagenBinPath, version, err := findSystemMutagenBin(); err == nil {
+			r.mutagenBinPath = systemMutagenBinPath
+			r.mutagenSetup.Version = version
+			r.mutagenSetup.BinPath = systemMutagenBinPath
+			return nil
+		}
+	}
+
+	mutagenBinPath, err := getWorkspaceMutagenBinPath()
+	if err != nil {
+		return err
+	}
+	r.mutagenSetup.BinPath = mutagenBinPath
+
+	if err := removeStaleMutagenArchives(filepath.Dir(mutagenBinPath), version); err != nil {
+		return err
+	}
 
 	stats, err := os.Stat(mutagenBinPath)
 	if err != nil && !errors.Is(err, os.ErrNotExist) {
 		return err
 	}
-	if err == nil && stats.Size() > 0 && !stats.IsDir() {
+	if err == nil && !stats.IsDir() {
+		if stats.Size() >= mutagenBinMinSize {
+			if err := ensureMutagenBinExecutable(mutagenBinPath); err != nil {
+				return err
+			}
+
+			// the provisioned version may have changed since the binary was installed
+			if installedVersion, err := getMutagenBinVersion(mutagenBinPath); err == nil && installedVersion == version {
+				return nil
+			}
+		}
+
+		// truncated by an interrupted extraction or outdated, provision it again
+		if err := os.Remove(mutagenBinPath); err != nil {
+			return err
+		}
+	}
+
+	if r.binFileMode&0100 == 0 {
+		return fmt.Errorf("mutagen binary file mode %o is not executable by the owner", r.binFileMode)
+	}
+
+	if r.bundledMutagenBinPath != "" {
+		if err := installBundledMutagenBin(r.bundledMutagenBinPath, mutagenBinPath, r.binFileMode, version); err != nil {
+			return err
+		}
+
+		r.runMutagenPostInstall(mutagenBinPath)
 		return nil
 	}
 
-	downloadFilename := fmt.Sprintf(mutagenDownloadFilename, runtime.GOOS, runtime.GOARCH, build.MutagenVersion)
-	mutagenArchivePath := filepath.Join(filepath.Dir(mutagenBinPath), downloadFilename)
-	downloadUrl := fmt.Sprintf(mutagenDownloadUrl, build.MutagenVersion, downloadFilename)
+	// patch releases newer than the known ones fail on download instead
+	if _, known := mutagenReleasePlatforms[version]; known || version == build.MutagenVersion {
+		if err := ensureMutagenReleasePlatform(version); err != nil {
+			return err
+		}
+	}
 
-	err = downloadMutagenArchive(downloadUrl, mutagenArchivePath)
+	cachedBinPath, downloaded, err := r.ensureCachedMutagenBin(version)
 	if err != nil {
 		return err
 	}
+	r.mutagenSetup.Downloaded = downloaded
 
-	err = extractMutagenBin(mutagenArchivePath, mutagenBinPath)
-	if err != nil {
+	if err := installCachedMutagenBin(cachedBinPath, mutagenBinPath, r.binFileMode); err != nil {
 		return err
 	}
 
-	return removeMutagenArchive(mutagenArchivePath)
+	r.runMutagenPostInstall(mutagenBinPath)
+	return nil
 }
 
-func removeMutagenArchive(filePath string) error {
-	return os.Remove(filePath)
-}
+// findSystemMutagenBin looks up a mutagen installed on PATH, accepted only when it matches the pinned minor version
+func findSystemMutagenBin() (string, string, error) {
+	systemMutagenBinPath, err := exec.LookPath(mutagenBinFilename)
+	if err != nil {
+		return "", "", err
+	}
 
-func downloadMutagenArchive(source, destination string) error {
-	// Configure the connection timeout
-	transport := &http.Transport{
-		DialContext: (&net.Dialer{
-			Timeout: 60 * time.Second,
-		}).DialContext,
+	version, err := getMutagenBinVersion(systemMutagenBinPath)
+	if err != nil {
+		return "", "", err
 	}
 
-	client := &http.Client{
-		Transport: transport,
+	if semver.MajorMinor(version) != semver.MajorMinor(build.MutagenVersion) {
+		return "", "", fmt.Errorf("system mutagen %s is %s, incompatible with %s", systemMutagenBinPath, version, build.MutagenVersion)
 	}
 
-	out, err := os.Create(destination)
+	return systemMutagenBinPath, version, nil
+}
+
+// verifyMutagenBinSize guards against truncated binaries, the most common corruption symptom
+func verifyMutagenBinSize(mutagenBinPath string) error {
+	stats, err := os.Stat(mutagenBinPath)
 	if err != nil {
 		return err
 	}
-	defer out.Close()
 
-	resp, err := client.Get(source)
+	if stats.Size() < mutagenBinMinSize {
+		return fmt.Errorf("mutagen binary %s is truncated (%d bytes)", mutagenBinPath, stats.Size())
+	}
+
+	return nil
+}
+
+// installBundledMutagenBin copies a mutagen binary shipped alongside the tool, no network access is needed
+func installBundledMutagenBin(source, destination string, mode os.FileMode, expectedVersion string) error {
+	if err := copyMutagenBin(source, destination, mode); err != nil {
+		return fmt.Errorf("cannot install bundled mutagen binary: %w", err)
+	}
+
+	version, err := getMutagenBinVersion(destination)
 	if err != nil {
+		os.Remove(destination)
 		return err
 	}
-	defer resp.Body.Close()
 
-	_, err = io.Copy(out, resp.Body)
-	return err
-}
+	if version != expectedVersion {
+		os.Remove(destination)
+		return fmt.Errorf("bundled mutagen binary %s is version %s, expected %s", source, version, expectedVersion)
+	}
 
-func extractMutagenBin(source, destination string) error {
-	return extractMutagenBinTarGz(source, destination)
+	return nil
 }
 
-func extractMutagenBinTarGz(source, destination string) error {
+func copyMutagenBin(source, destination string, mode os.FileMode) error {
 	sourceFile, err := os.Open(source)
 	if err != nil {
 		return err
 	}
 	defer sourceFile.Close()
 
-	gzipReader, err := gzip.NewReader(sourceFile)
+	destinationFile, err := os.OpenFile(destination, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
 	if err != nil {
 		return err
 	}
-	defer gzipReader.Close()
-
-	tarReader := tar.NewReader(gzipReader)
-
-	for {
-		header, err := tarReader.Next()
+	defer destinationFile.Close()
 
-		if err == io.EOF {
-			break
-		}
+	if _, err := io.Copy(destinationFile, sourceFile); err != nil {
+		destinationFile.Close()
+		os.Remove(destination)
+		return err
+	}
+	destinationFile.Close()
 
-		if err != nil {
-			return err
-		}
+	if err := os.Chmod(destination, mode); err != nil {
+		return err
+	}
 
-		if header.Name == getMutagenBinFilename() {
-			destinationFile, err := os.OpenFile(destination, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, header.FileInfo().Mode())
-			if err != nil {
-				return err
-			}
-			defer destinationFile.Close()
+	return ensureMutagenBinExecutable(destination)
+}
 
-			if _, err := io.Copy(destinationFile, tarReader); err != nil {
-				return err
-			}
-			return nil
-		}
+// getMutagenBinVersion returns the version reported by the binary, in the "v0.0.0" format of build.MutagenVersion
+func getMutagenBinVersion(mutagenBinPath string) (string, error) {
+	output, err := exec.Command(mutagenBinPath, "version").Output()
+	if err != nil {
+		return "", fmt.Errorf("cannot determine the version of %s: %w", mutagenBinPath, err)
 	}
 
-	return nil
+	return "v" + strings.TrimPrefix(strings.TrimSpace(string(output)), "v"), nil
 }
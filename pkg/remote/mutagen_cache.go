@@ -0,0 +1,154 @@
+package remote
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	mutagenCacheDirname      = "bunnyshell"
+	mutagenCacheLockFilename = ".lock"
+	// mutagenCacheChecksumFilename records the sha256 of the cached binary, checked before every install
+	mutagenCacheChecksumFilename = "mutagen.sha256"
+	// mutagenCacheUnpinnedDirname holds the downloads without a provisioned archive checksum
+	mutagenCacheUnpinnedDirname = "unpinned"
+
+	mutagenCacheLockTimeout = 5 * time.Minute
+	mutagenCacheLockStale   = 10 * time.Minute
+	mutagenCacheLockPoll    = 250 * time.Millisecond
+)
+
+// serializes cache access within the process, the lock file covers other processes
+var mutagenCacheMutex sync.Mutex
+
+// ensureCachedMutagenBin provisions the binary in a machine wide cache shared by all workspaces,
+// so a version is downloaded only once per machine. It reports whether a download was needed.
+// The cache is keyed by the provisioned archive checksum too, a build with another checksum is never reused.
+func (r *RemoteDevelopment) ensureCachedMutagenBin(version string) (string, bool, error) {
+	options := r.getMutagenDownloadOptions(version)
+
+	cacheDir, err := getMutagenCacheDir(version, options.checksum)
+	if err != nil {
+		return "", false, err
+	}
+
+	mutagenCacheMutex.Lock()
+	defer mutagenCacheMutex.Unlock()
+
+	release, err := lockMutagenCache(cacheDir)
+	if err != nil {
+		return "", false, err
+	}
+	defer release()
+
+	cachedBinPath := filepath.Join(cacheDir, getMutagenBinFilename())
+	checksumFilePath := filepath.Join(cacheDir, mutagenCacheChecksumFilename)
+	if err := verifyCachedMutagenBin(cachedBinPath, checksumFilePath); err == nil {
+		return cachedBinPath, false, nil
+	}
+
+	if err := downloadMutagenBin(version, runtime.GOOS, runtime.GOARCH, cachedBinPath, r.binFileMode, options); err != nil {
+		return "", false, err
+	}
+
+	binChecksum, err := verifyMutagenArchiveChecksum(cachedBinPath, "")
+	if err != nil {
+		return "", false, err
+	}
+	if err := os.WriteFile(checksumFilePath, []byte(binChecksum), 0600); err != nil {
+		return "", false, err
+	}
+
+	return cachedBinPath, true, nil
+}
+
+// verifyCachedMutagenBin rejects a truncated binary and one that changed since it was downloaded
+func verifyCachedMutagenBin(cachedBinPath, checksumFilePath string) error {
+	if err := verifyMutagenBinSize(cachedBinPath); err != nil {
+		return err
+	}
+
+	checksum, err := os.ReadFile(checksumFilePath)
+	if err != nil {
+		return err
+	}
+
+	_, err = verifyMutagenArchiveChecksum(cachedBinPath, strings.TrimSpace(string(checksum)))
+	return err
+}
+
+// installCachedMutagenBin hardlinks the cached binary into the workspace, copying when linking is not possible
+func installCachedMutagenBin(cachedBinPath, mutagenBinPath string, mode os.FileMode) error {
+	if err := os.Remove(mutagenBinPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	if err := os.Link(cachedBinPath, mutagenBinPath); err == nil {
+		return ensureMutagenBinExecutable(mutagenBinPath)
+	}
+
+	return copyMutagenBin(cachedBinPath, mutagenBinPath, mode)
+}
+
+func getMutagenCacheDir(version, checksum string) (string, error) {
+	userCacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	if checksum == "" {
+		checksum = mutagenCacheUnpinnedDirname
+	}
+
+	cacheDir := filepath.Join(
+		userCacheDir,
+		mutagenCacheDirname,
+		mutagenBinFilename,
+		version,
+		fmt.Sprintf("%s_%s", runtime.GOOS, runtime.GOARCH),
+		checksum,
+	)
+	if err := os.MkdirAll(cacheDir, 0700); err != nil {
+		return "", err
+	}
+
+	return cacheDir, nil
+}
+
+func lockMutagenCache(cacheDir string) (func(), error) {
+	lockFilePath := filepath.Join(cacheDir, mutagenCacheLockFilename)
+	deadline := time.Now().Add(mutagenCacheLockTimeout)
+
+	for {
+		lockFile, err := os.OpenFile(lockFilePath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err == nil {
+			lockFile.Close()
+
+			return func() {
+				os.Remove(lockFilePath)
+			}, nil
+		}
+
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+
+		// left behind by a killed process
+		if stats, err := os.Stat(lockFilePath); err == nil && time.Since(stats.ModTime()) > mutagenCacheLockStale {
+			os.Remove(lockFilePath)
+			continue
+		}
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for the mutagen cache lock %s", lockFilePath)
+		}
+
+		time.Sleep(mutagenCacheLockPoll)
+	}
+}
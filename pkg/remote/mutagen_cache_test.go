@@ -0,0 +1,61 @@
+package remote
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestVerifyCachedMutagenBin(t *testing.T) {
+	cacheDir := t.TempDir()
+	cachedBinPath := filepath.Join(cacheDir, mutagenBinFilename)
+	checksumFilePath := filepath.Join(cacheDir, mutagenCacheChecksumFilename)
+
+	binary := bytes.Repeat([]byte("m"), mutagenBinMinSize)
+	if err := os.WriteFile(cachedBinPath, binary, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := verifyCachedMutagenBin(cachedBinPath, checksumFilePath); err == nil {
+		t.Error("accepted a binary without a recorded checksum")
+	}
+
+	checksum, err := verifyMutagenArchiveChecksum(cachedBinPath, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(checksumFilePath, []byte(checksum), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyCachedMutagenBin(cachedBinPath, checksumFilePath); err != nil {
+		t.Errorf("rejected the downloaded binary: %v", err)
+	}
+
+	// same size, other content, e.g. a truncated binary padded back or another build
+	binary[len(binary)-1] = 'x'
+	if err := os.WriteFile(cachedBinPath, binary, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyCachedMutagenBin(cachedBinPath, checksumFilePath); err == nil {
+		t.Error("accepted a binary that changed since it was downloaded")
+	}
+}
+
+func TestMutagenCacheDirIsKeyedByChecksum(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	pinned, err := getMutagenCacheDir("v0.15.3", "0123abcd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	unpinned, err := getMutagenCacheDir("v0.15.3", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if pinned == unpinned || filepath.Base(pinned) != "0123abcd" {
+		t.Errorf("got cache dirs %s and %s", pinned, unpinned)
+	}
+}
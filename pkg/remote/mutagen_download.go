@@ -0,0 +1,584 @@
+package remote
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"bunnyshell.com/dev/pkg/build"
+)
+
+const (
+	// CABundleEnvVar points to PEM certificates trusted for the mutagen download on top of the system roots,
+	// e.g. the CA of a TLS-inspecting proxy
+	CABundleEnvVar = "BNS_CA_BUNDLE"
+
+	// OfflineEnvVar set to a true value, e.g. 1, forbids downloading mutagen, see ErrDownloadDisabled
+	OfflineEnvVar = "BNS_MUTAGEN_OFFLINE"
+
+	// TempDirEnvVar is where mutagen archives are downloaded and extracted, instead of next to the binary
+	TempDirEnvVar = "BNS_TMPDIR"
+
+	defaultMutagenBinFileMode os.FileMode = 0700
+
+	mutagenExtractAttempts     = 4
+	mutagenExtractRetryBackoff = 500 * time.Millisecond
+
+	// defaultDownloadBufferSize amortizes the write syscalls better than the 32KB of io.Copy on fast links
+	defaultDownloadBufferSize = 256 << 10
+
+	// mutagenRequiredDiskSpace covers the release archive, which bundles the agents, plus the extracted binary
+	mutagenRequiredDiskSpace = 128 << 20
+)
+
+var (
+	ErrDownloadDisabled = fmt.Errorf("mutagen download is disabled")
+)
+
+// mutagenArchiveExp matches the archives named after mutagenDownloadFilename, other files are never touched
+var mutagenArchiveExp = regexp.MustCompile(`^mutagen_[a-z0-9]+_[a-z0-9]+_v[0-9]+\.[0-9]+\.[0-9]+(-[0-9A-Za-z.-]+)?\.tar\.gz$`)
+
+// EnsureMutagenBinary provisions the mutagen binary of version for the current platform in destDir,
+// downloading it only when missing, truncated or of another version
+func EnsureMutagenBinary(version, destDir string) (string, error) {
+	return EnsureMutagenBinaryFor(version, runtime.GOOS, runtime.GOARCH, destDir)
+}
+
+// EnsureMutagenBinaryFor provisions the mutagen binary of version for goos/goarch in destDir, e.g. to build bundles.
+// A binary of another platform cannot be run to check its version, so it is downloaded again every time.
+func EnsureMutagenBinaryFor(version, goos, goarch, destDir string) (string, error) {
+	mutagenBinPath := filepath.Join(destDir, getMutagenBinFilenameFor(goos))
+
+	if goos == runtime.GOOS && goarch == runtime.GOARCH {
+		if err := verifyMutagenBinSize(mutagenBinPath); err == nil {
+			if installedVersion, err := getMutagenBinVersion(mutagenBinPath); err == nil && installedVersion == version {
+				return mutagenBinPath, ensureMutagenBinExecutable(mutagenBinPath)
+			}
+		}
+	}
+
+	if err := DownloadAndExtract(version, goos, goarch, destDir); err != nil {
+		return "", err
+	}
+
+	return mutagenBinPath, nil
+}
+
+// DownloadAndExtract downloads the mutagen release archive of version for goos/goarch and extracts the binary in destDir
+func DownloadAndExtract(version, goos, goarch, destDir string) error {
+	if err := os.MkdirAll(destDir, 0700); err != nil {
+		return err
+	}
+
+	if err := validateMutagenVersion(version); err != nil {
+		return err
+	}
+
+	// patch releases newer than the known ones fail on download instead
+	if _, known := mutagenReleasePlatforms[version]; known {
+		if err := ensureMutagenReleasePlatformFor(version, goos, goarch); err != nil {
+			return err
+		}
+	}
+
+	mutagenBinPath := filepath.Join(destDir, getMutagenBinFilenameFor(goos))
+	if err := os.Remove(mutagenBinPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	provision, err := loadMutagenProvision()
+	if err != nil {
+		return err
+	}
+
+	options := newMutagenDownloadOptions(provision, version, goos, goarch)
+	options.caBundlePath = os.Getenv(CABundleEnvVar)
+
+	return downloadMutagenBin(version, goos, goarch, mutagenBinPath, defaultMutagenBinFileMode, options)
+}
+
+type mutagenDownloadOptions struct {
+	headers      http.Header
+	caBundlePath string
+
+	baseUrl  string
+	checksum string
+	attempts int
+	timeout  time.Duration
+
+	disableCompression bool
+	disableDownload    bool
+
+	tempDir    string
+	bufferSize int
+
+	// ctx cancels the download, nil never does
+	ctx context.Context
+
+	verifySignature bool
+	signingKey      string
+
+	onDownloaded func(path, version, checksum string)
+}
+
+// newMutagenDownloadOptions applies the provision settings, its checksums are only trusted for its own version
+func newMutagenDownloadOptions(provision *MutagenProvision, version, goos, goarch string) mutagenDownloadOptions {
+	options := mutagenDownloadOptions{
+		baseUrl:  strings.TrimSuffix(provision.BaseURL, "/"),
+		attempts: provision.Attempts,
+		timeout:  provision.Timeout,
+
+		disableDownload: isOfflineEnv(),
+		tempDir:         os.Getenv(TempDirEnvVar),
+
+		verifySignature: provision.VerifySignature,
+		signingKey:      provision.SigningKey,
+	}
+
+	if version == provision.Version {
+		options.checksum = provision.Checksums[fmt.Sprintf("%s/%s", goos, goarch)]
+	}
+
+	return options
+}
+
+func (r *RemoteDevelopment) getMutagenDownloadOptions(version string) mutagenDownloadOptions {
+	options := newMutagenDownloadOptions(r.mutagenProvision, version, runtime.GOOS, runtime.GOARCH)
+	options.headers = r.downloadHeaders
+	options.disableCompression = r.disableDownloadCompression
+	options.disableDownload = options.disableDownload || r.disableDownload
+	if r.tempDir != "" {
+		options.tempDir = r.tempDir
+	}
+
+	options.onDownloaded = r.onDownloaded
+	options.bufferSize = r.downloadBufferSize
+	options.ctx = r.downloadContext
+
+	options.caBundlePath = r.caBundlePath
+	if options.caBundlePath == "" {
+		options.caBundlePath = os.Getenv(CABundleEnvVar)
+	}
+
+	return options
+}
+
+func getMutagenBinFilenameFor(goos string) string {
+	if goos == "windows" {
+		return mutagenBinFilename + ".exe"
+	}
+
+	return mutagenBinFilename
+}
+
+// downloadMutagenBin downloads the release archive next to mutagenBinPath and extracts the binary from it
+func downloadMutagenBin(version, goos, goarch, mutagenBinPath string, mode os.FileMode, options mutagenDownloadOptions) error {
+	// with a temp dir, the binary is extracted there too and moved in place once verified
+	workDir := filepath.Dir(mutagenBinPath)
+	extractedBinPath := mutagenBinPath
+	if options.tempDir != "" {
+		workDir = options.tempDir
+		extractedBinPath = filepath.Join(workDir, fmt.Sprintf("%s-%s", getMutagenBinFilenameFor(goos), version))
+	}
+
+	downloadFilename := fmt.Sprintf(mutagenDownloadFilename, goos, goarch, version)
+	mutagenArchivePath := filepath.Join(workDir, downloadFilename)
+	downloadUrl := fmt.Sprintf(mutagenDownloadUrl, options.baseUrl, version, downloadFilename)
+
+	// the single gate before any outbound connection
+	if options.disableDownload {
+		return fmt.Errorf(
+			"%w: mutagen %s is not installed, provide it through the workspace, a bundled binary or the system PATH",
+			ErrDownloadDisabled,
+			version,
+		)
+	}
+
+	if err := ensureFreeDiskSpace(workDir, mutagenRequiredDiskSpace); err != nil {
+		return err
+	}
+
+	downloadError := &DownloadError{URL: downloadUrl}
+	startTime := time.Now()
+
+	archiveChecksum := ""
+
+	// a failed download or a corrupt archive (e.g. an error page served with a success status) is fetched once more
+	for attempt := 1; ; attempt++ {
+		attemptStartTime := time.Now()
+
+		err := downloadMutagenArchive(downloadUrl, mutagenArchivePath, options)
+		if err == nil {
+			archiveChecksum, err = verifyMutagenArchiveChecksum(mutagenArchivePath, options.checksum)
+		}
+		if err == nil && options.verifySignature {
+			err = verifyMutagenArchiveSignature(downloadUrl, mutagenArchivePath, options)
+			// a bad signature or key is not transient, the archive is not trusted and re-running won't help
+			if errors.Is(err, ErrSignatureVerification) {
+				removeMutagenArchive(mutagenArchivePath)
+				return err
+			}
+		}
+		if err == nil {
+			err = extractMutagenBinWithRetry(mutagenArchivePath, getMutagenBinFilenameFor(goos), extractedBinPath, mode)
+		}
+		if err == nil {
+			err = verifyMutagenBinSize(extractedBinPath)
+		}
+		if err == nil && extractedBinPath != mutagenBinPath {
+			err = moveMutagenBin(extractedBinPath, mutagenBinPath, mode)
+		}
+		if err == nil {
+			break
+		}
+
+		removeMutagenArchive(mutagenArchivePath)
+		os.Remove(extractedBinPath)
+		os.Remove(mutagenBinPath)
+
+		downloadAttempt := DownloadAttempt{Err: err, Duration: time.Since(attemptStartTime)}
+		statusError := &downloadStatusError{}
+		if errors.As(err, &statusError) {
+			downloadAttempt.StatusCode = statusError.statusCode
+		}
+		downloadError.Attempts = append(downloadError.Attempts, downloadAttempt)
+
+		cancelled := options.ctx != nil && options.ctx.Err() != nil
+		if cancelled || attempt >= options.attempts || (downloadAttempt.StatusCode != 0 && statusError.isPermanent()) {
+			downloadError.Elapsed = time.Since(startTime)
+			return downloadError
+		}
+	}
+
+	if err := ensureMutagenBinExecutable(mutagenBinPath); err != nil {
+		return err
+	}
+
+	if options.onDownloaded != nil {
+		options.onDownloaded(mutagenBinPath, version, archiveChecksum)
+	}
+
+	return removeMutagenArchive(mutagenArchivePath)
+}
+
+// ensureFreeDiskSpace fails early instead of leaving a truncated archive or binary behind on a full disk
+func ensureFreeDiskSpace(dir string, required uint64) error {
+	available, err := getFreeDiskSpace(dir)
+	if err != nil {
+		// not all filesystems report it, the download may still succeed
+		return nil
+	}
+
+	if available < required {
+		return fmt.Errorf("insufficient disk space in %s: need ~%d MiB, have %d MiB", dir, required>>20, available>>20)
+	}
+
+	return nil
+}
+
+// verifyMutagenArchiveChecksum returns the sha256 of the archive, compared to checksum when one is provisioned.
+// The cache checks its binaries with it too.
+func verifyMutagenArchiveChecksum(mutagenArchivePath, checksum string) (string, error) {
+	file, err := os.Open(mutagenArchivePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+
+	actual := hex.EncodeToString(hash.Sum(nil))
+	if checksum != "" && actual != checksum {
+		return "", fmt.Errorf("checksum mismatch for %s: expected %s, got %s", filepath.Base(mutagenArchivePath), checksum, actual)
+	}
+
+	return actual, nil
+}
+
+// moveMutagenBin renames atomically within a filesystem and falls back to a copy across filesystems
+func moveMutagenBin(source, destination string, mode os.FileMode) error {
+	if err := os.Rename(source, destination); err == nil {
+		return nil
+	}
+
+	if err := copyMutagenBin(source, destination, mode); err != nil {
+		return err
+	}
+
+	return os.Remove(source)
+}
+
+func removeMutagenArchive(filePath string) error {
+	return os.Remove(filePath)
+}
+
+// removeStaleMutagenArchives deletes archives left in dir by interrupted downloads of other versions or platforms
+func removeStaleMutagenArchives(dir, version string) error {
+	currentArchive := fmt.Sprintf(mutagenDownloadFilename, runtime.GOOS, runtime.GOARCH, version)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || entry.Name() == currentArchive || !mutagenArchiveExp.MatchString(entry.Name()) {
+			continue
+		}
+
+		if err := removeMutagenArchive(filepath.Join(dir, entry.Name())); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func downloadMutagenArchive(source, destination string, options mutagenDownloadOptions) error {
+	client, err := newDownloadClient(options)
+	if err != nil {
+		return err
+	}
+
+	ctx := options.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+	if err != nil {
+		return err
+	}
+	for name, values := range options.headers {
+		for _, value := range values {
+			request.Header.Add(name, value)
+		}
+	}
+	request.Header.Set("User-Agent", getDownloadUserAgent())
+
+	resp, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return &downloadStatusError{
+			statusCode: resp.StatusCode,
+			message:    fmt.Sprintf("cannot download %s with headers %v: unexpected status %s", source, getHeaderNames(request.Header), resp.Status),
+		}
+	}
+
+	out, err := os.Create(destination)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	if _, err = copyDownload(out, resp.Body, options.bufferSize); err != nil {
+		out.Close()
+		os.Remove(destination)
+		return err
+	}
+
+	return nil
+}
+
+// copyDownload copies through a buffer of bufferSize, the default one when not positive
+func copyDownload(destination io.Writer, source io.Reader, bufferSize int) (int64, error) {
+	if bufferSize <= 0 {
+		bufferSize = defaultDownloadBufferSize
+	}
+
+	// hiding ReadFrom and WriteTo keeps e.g. *os.File or the source from copying with their own buffer
+	writer := struct{ io.Writer }{destination}
+	reader := struct{ io.Reader }{source}
+
+	return io.CopyBuffer(writer, reader, make([]byte, bufferSize))
+}
+
+func newDownloadClient(options mutagenDownloadOptions) (*http.Client, error) {
+	// Configure the connection timeout
+	transport := &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: options.timeout,
+		}).DialContext,
+		// the archive is already compressed, some proxies mangle transparently compressed responses
+		DisableCompression: options.disableCompression,
+	}
+
+	if options.caBundlePath != "" {
+		rootCAs, err := loadCABundle(options.caBundlePath)
+		if err != nil {
+			return nil, err
+		}
+
+		transport.TLSClientConfig = &tls.Config{
+			RootCAs: rootCAs,
+		}
+	}
+
+	return &http.Client{
+		Transport: transport,
+	}, nil
+}
+
+// loadCABundle adds the certificates of caBundlePath to the system roots
+func loadCABundle(caBundlePath string) (*x509.CertPool, error) {
+	rootCAs, err := x509.SystemCertPool()
+	if err != nil {
+		rootCAs = x509.NewCertPool()
+	}
+
+	data, err := os.ReadFile(caBundlePath)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read CA bundle %s: %w", caBundlePath, err)
+	}
+
+	if !rootCAs.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("CA bundle %s contains no PEM certificates", caBundlePath)
+	}
+
+	return rootCAs, nil
+}
+
+func isOfflineEnv() bool {
+	offline, _ := strconv.ParseBool(os.Getenv(OfflineEnvVar))
+	return offline
+}
+
+func getDownloadUserAgent() string {
+	return fmt.Sprintf("%s/%s mutagen/%s", build.Name, build.Version, build.MutagenVersion)
+}
+
+// getHeaderNames leaves the values out, any header may carry a mirror's credentials, e.g. X-JFrog-Art-Api
+func getHeaderNames(headers http.Header) []string {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// extractMutagenBinWithRetry retries while the destination is locked, other failures such as a full disk
+// or a corrupt archive are returned right away
+func extractMutagenBinWithRetry(source, entryName, destination string, mode os.FileMode) error {
+	delay := mutagenExtractRetryBackoff
+	for attempt := 1; ; attempt++ {
+		err := extractMutagenBin(source, entryName, destination, mode)
+		if err == nil || attempt >= mutagenExtractAttempts || !isTransientExtractError(err) {
+			return err
+		}
+
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+func extractMutagenBin(source, entryName, destination string, mode os.FileMode) error {
+	return extractMutagenBinTarGz(source, entryName, destination, mode)
+}
+
+// extractMutagenBinTarGz applies mode explicitly, the tar header mode and the umask are ignored
+func extractMutagenBinTarGz(source, entryName, destination string, mode os.FileMode) error {
+	extracted, err := extractTarGzEntries(source, func(name string) string {
+		if name == entryName {
+			return destination
+		}
+
+		return ""
+	}, mode)
+	if err != nil {
+		return err
+	}
+
+	if len(extracted) == 0 {
+		return fmt.Errorf("%s not found in archive %s", entryName, source)
+	}
+
+	return nil
+}
+
+// extractTarGzEntries extracts the regular files for which destinationOf returns a path, e.g. the binary
+// and a bundled agent, and returns their entry names. The caller decides which missing entries are fatal.
+func extractTarGzEntries(source string, destinationOf func(entryName string) string, mode os.FileMode) ([]string, error) {
+	sourceFile, err := os.Open(source)
+	if err != nil {
+		return nil, err
+	}
+	defer sourceFile.Close()
+
+	gzipReader, err := gzip.NewReader(sourceFile)
+	if err != nil {
+		return nil, err
+	}
+	defer gzipReader.Close()
+
+	tarReader := tar.NewReader(gzipReader)
+
+	extracted := []string{}
+	for {
+		header, err := tarReader.Next()
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		destination := destinationOf(header.Name)
+		if destination == "" {
+			continue
+		}
+
+		if err := extractTarEntry(tarReader, destination, mode); err != nil {
+			return nil, err
+		}
+
+		extracted = append(extracted, header.Name)
+	}
+
+	return extracted, nil
+}
+
+func extractTarEntry(tarReader *tar.Reader, destination string, mode os.FileMode) error {
+	destinationFile, err := os.OpenFile(destination, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	defer destinationFile.Close()
+
+	if _, err := io.Copy(destinationFile, tarReader); err != nil {
+		return err
+	}
+
+	return os.Chmod(destination, mode)
+}
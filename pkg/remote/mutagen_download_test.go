@@ -0,0 +1,104 @@
+package remote
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// recordingWriter records the size of every write, ReadFrom fails the test if the copy bypasses the buffer
+type recordingWriter struct {
+	t      *testing.T
+	writes []int
+}
+
+func (w *recordingWriter) Write(p []byte) (int, error) {
+	w.writes = append(w.writes, len(p))
+	return len(p), nil
+}
+
+func (w *recordingWriter) ReadFrom(r io.Reader) (int64, error) {
+	w.t.Error("copyDownload used ReadFrom instead of its buffer")
+	return io.Copy(io.Discard, r)
+}
+
+func TestCopyDownloadBufferSize(t *testing.T) {
+	data := bytes.Repeat([]byte("m"), 3*defaultDownloadBufferSize+10)
+
+	for _, bufferSize := range []int{0, 4 << 10, defaultDownloadBufferSize} {
+		t.Run(fmt.Sprintf("buffer %d", bufferSize), func(t *testing.T) {
+			expectedSize := bufferSize
+			if expectedSize <= 0 {
+				expectedSize = defaultDownloadBufferSize
+			}
+
+			writer := &recordingWriter{t: t}
+			written, err := copyDownload(writer, bytes.NewReader(data), bufferSize)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if written != int64(len(data)) {
+				t.Errorf("copied %d bytes, want %d", written, len(data))
+			}
+
+			largest := 0
+			for _, size := range writer.writes {
+				largest = max(largest, size)
+			}
+			if largest != expectedSize {
+				t.Errorf("got writes of up to %d bytes, want %d", largest, expectedSize)
+			}
+		})
+	}
+}
+
+func TestDownloadStatusErrorHidesHeaderValues(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	options := mutagenDownloadOptions{headers: http.Header{}}
+	options.headers.Set("X-JFrog-Art-Api", "mirror-secret")
+	options.headers.Set("Authorization", "Bearer token-secret")
+
+	err := downloadMutagenArchive(server.URL, filepath.Join(t.TempDir(), "mutagen.tar.gz"), options)
+	if err == nil {
+		t.Fatal("expected an unexpected status error")
+	}
+
+	if strings.Contains(err.Error(), "secret") {
+		t.Errorf("the error leaks a header value: %s", err)
+	}
+	if !strings.Contains(err.Error(), "X-Jfrog-Art-Api") {
+		t.Errorf("the error does not name the header: %s", err)
+	}
+}
+
+func BenchmarkDownloadMutagenArchive(b *testing.B) {
+	archive := bytes.Repeat([]byte("mutagen"), 32<<20/7)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(archive)
+	}))
+	defer server.Close()
+
+	destination := filepath.Join(b.TempDir(), "mutagen.tar.gz")
+
+	for _, bufferSize := range []int{4 << 10, 32 << 10, defaultDownloadBufferSize, 1 << 20} {
+		b.Run(fmt.Sprintf("buffer %dKB", bufferSize>>10), func(b *testing.B) {
+			options := mutagenDownloadOptions{bufferSize: bufferSize, disableCompression: true}
+
+			b.SetBytes(int64(len(archive)))
+			for i := 0; i < b.N; i++ {
+				if err := downloadMutagenArchive(server.URL, destination, options); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
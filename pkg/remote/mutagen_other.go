@@ -3,6 +3,38 @@
 
 package remote
 
+import (
+	"fmt"
+	"os"
+)
+
 func getMutagenBinFilename() string {
 	return mutagenBinFilename
 }
+
+// ensureMutagenBinExecutable adds the owner executable bit when the archive didn't carry it
+func ensureMutagenBinExecutable(mutagenBinPath string) error {
+	stats, err := os.Stat(mutagenBinPath)
+	if err != nil {
+		return err
+	}
+
+	if stats.Mode().Perm()&0100 != 0 {
+		return nil
+	}
+
+	if err := os.Chmod(mutagenBinPath, stats.Mode().Perm()|0100); err != nil {
+		return fmt.Errorf("cannot make %s executable: %w", mutagenBinPath, err)
+	}
+
+	stats, err = os.Stat(mutagenBinPath)
+	if err != nil {
+		return err
+	}
+
+	if stats.Mode().Perm()&0100 == 0 {
+		return fmt.Errorf("cannot make %s executable, check the filesystem mount options", mutagenBinPath)
+	}
+
+	return nil
+}
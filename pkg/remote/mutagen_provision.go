@@ -0,0 +1,152 @@
+package remote
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"bunnyshell.com/dev/pkg/build"
+	"bunnyshell.com/dev/pkg/util"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// ProvisionFileEnvVar points to a mutagen-provision.yaml, defaults to the one in the remote-dev workspace
+	ProvisionFileEnvVar = "BNS_MUTAGEN_PROVISION"
+
+	mutagenProvisionFilename = "mutagen-provision.yaml"
+
+	mutagenDownloadBaseUrl = "https://github.com/mutagen-io/mutagen/releases/download"
+	mutagenDownloadTimeout = 60 * time.Second
+)
+
+var (
+	mutagenPlatformExp = regexp.MustCompile("^[a-z0-9]+/[a-z0-9]+$")
+	sha256Exp          = regexp.MustCompile("^[a-f0-9]{64}$")
+)
+
+// MutagenProvision lets platform teams manage how the mutagen binary is provisioned, e.g.:
+//
+//	version: v0.15.3
+//	baseUrl: https://artifacts.example.com/mutagen
+//	checksums:
+//	  linux/amd64: <sha256 of mutagen_linux_amd64_v0.15.3.tar.gz>
+//	attempts: 3
+//	timeout: 2m
+//	verifySignature: true
+//	signingKey: |
+//	  -----BEGIN PGP PUBLIC KEY BLOCK-----
+//	  ...
+//
+// Archives are downloaded from <baseUrl>/<version>/<archive>, omitted fields keep the compiled-in defaults.
+// With verifySignature, <archive>.asc must be published next to each archive and be signed by signingKey.
+type MutagenProvision struct {
+	Version   string            `yaml:"version,omitempty"`
+	BaseURL   string            `yaml:"baseUrl,omitempty"`
+	Checksums map[string]string `yaml:"checksums,omitempty"`
+	Attempts  int               `yaml:"attempts,omitempty"`
+	Timeout   time.Duration     `yaml:"timeout,omitempty"`
+
+	VerifySignature bool   `yaml:"verifySignature,omitempty"`
+	SigningKey      string `yaml:"signingKey,omitempty"`
+}
+
+func newDefaultMutagenProvision() *MutagenProvision {
+	return &MutagenProvision{
+		Version:  build.MutagenVersion,
+		BaseURL:  mutagenDownloadBaseUrl,
+		Attempts: mutagenDownloadAttempts,
+		Timeout:  mutagenDownloadTimeout,
+	}
+}
+
+// Validate only accepts versions of the pinned minor release, the commands used are not stable across minor releases
+func (p *MutagenProvision) Validate() error {
+	if err := validateMutagenVersion(p.Version); err != nil {
+		return err
+	}
+
+	baseUrl, err := url.Parse(p.BaseURL)
+	if err != nil || (baseUrl.Scheme != "https" && baseUrl.Scheme != "http") || baseUrl.Host == "" {
+		return fmt.Errorf("baseUrl %q is not an http(s) URL", p.BaseURL)
+	}
+
+	for platform, checksum := range p.Checksums {
+		if !mutagenPlatformExp.MatchString(platform) {
+			return fmt.Errorf("checksum platform %q is not in the os/arch format", platform)
+		}
+
+		if !sha256Exp.MatchString(checksum) {
+			return fmt.Errorf("checksum of %s is not a lowercase hex sha256", platform)
+		}
+	}
+
+	if p.Attempts < 1 {
+		return fmt.Errorf("attempts must be at least 1")
+	}
+
+	if p.Timeout <= 0 {
+		return fmt.Errorf("timeout must be positive")
+	}
+
+	if p.VerifySignature {
+		if p.SigningKey == "" {
+			return fmt.Errorf("verifySignature requires a signingKey")
+		}
+
+		if _, err := parseSigningKey(p.SigningKey); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// loadMutagenProvision reads the provision file, the compiled-in defaults are used when there is none
+func loadMutagenProvision() (*MutagenProvision, error) {
+	provisionFilePath, err := getMutagenProvisionFilePath()
+	if err != nil {
+		return nil, err
+	}
+
+	provision := newDefaultMutagenProvision()
+
+	data, err := os.ReadFile(provisionFilePath)
+	if errors.Is(err, os.ErrNotExist) && os.Getenv(ProvisionFileEnvVar) == "" {
+		return provision, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read mutagen provision file %s: %w", provisionFilePath, err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(provision); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("cannot parse mutagen provision file %s: %w", provisionFilePath, err)
+	}
+
+	if err := provision.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid mutagen provision file %s: %w", provisionFilePath, err)
+	}
+
+	return provision, nil
+}
+
+func getMutagenProvisionFilePath() (string, error) {
+	if provisionFilePath := os.Getenv(ProvisionFileEnvVar); provisionFilePath != "" {
+		return provisionFilePath, nil
+	}
+
+	workspaceDir, err := util.GetRemoteDevWorkspaceDirPath()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(workspaceDir, mutagenProvisionFilename), nil
+}
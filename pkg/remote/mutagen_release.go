@@ -0,0 +1,84 @@
+package remote
+
+import (
+	"fmt"
+	"runtime"
+	"slices"
+	"strings"
+
+	"bunnyshell.com/dev/pkg/build"
+
+	"golang.org/x/mod/semver"
+)
+
+var (
+	ErrUnsupportedMutagenVersion = fmt.Errorf("unsupported mutagen version")
+)
+
+// mutagenReleasePlatforms lists the os/arch pairs published for each mutagen release
+var mutagenReleasePlatforms = map[string][]string{
+	"v0.15.3": {
+		"darwin/amd64", "darwin/arm64",
+		"freebsd/386", "freebsd/amd64", "freebsd/arm", "freebsd/arm64",
+		"linux/386", "linux/amd64", "linux/arm", "linux/arm64",
+		"linux/mips", "linux/mips64", "linux/mips64le", "linux/mipsle",
+		"linux/ppc64", "linux/ppc64le", "linux/riscv64", "linux/s390x",
+		"netbsd/386", "netbsd/amd64", "netbsd/arm",
+		"openbsd/386", "openbsd/amd64", "openbsd/arm", "openbsd/arm64",
+		"solaris/amd64",
+		"windows/386", "windows/amd64", "windows/arm", "windows/arm64",
+	},
+}
+
+// SupportedMutagenVersions lists the releases known to work, sorted. Other patch releases of the same minor
+// as build.MutagenVersion are accepted too, the config schema only changes between minor releases.
+func SupportedMutagenVersions() []string {
+	versions := make([]string, 0, len(mutagenReleasePlatforms))
+	for version := range mutagenReleasePlatforms {
+		versions = append(versions, version)
+	}
+	semver.Sort(versions)
+
+	return versions
+}
+
+func validateMutagenVersion(version string) error {
+	if _, known := mutagenReleasePlatforms[version]; known {
+		return nil
+	}
+
+	if semver.IsValid(version) && semver.MajorMinor(version) == semver.MajorMinor(build.MutagenVersion) {
+		return nil
+	}
+
+	return fmt.Errorf(
+		"%w %q, supported: %s or any %s.x release",
+		ErrUnsupportedMutagenVersion,
+		version,
+		strings.Join(SupportedMutagenVersions(), ", "),
+		semver.MajorMinor(build.MutagenVersion),
+	)
+}
+
+func ensureMutagenReleasePlatform(version string) error {
+	return ensureMutagenReleasePlatformFor(version, runtime.GOOS, runtime.GOARCH)
+}
+
+func ensureMutagenReleasePlatformFor(version, goos, goarch string) error {
+	platforms, ok := mutagenReleasePlatforms[version]
+	if !ok {
+		return fmt.Errorf("mutagen %s is not a known release", version)
+	}
+
+	platform := fmt.Sprintf("%s/%s", goos, goarch)
+	if !slices.Contains(platforms, platform) {
+		return fmt.Errorf(
+			"mutagen %s has no release for %s, supported platforms: %s",
+			version,
+			platform,
+			strings.Join(platforms, ", "),
+		)
+	}
+
+	return nil
+}
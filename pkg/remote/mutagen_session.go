@@ -0,0 +1,471 @@
+package remote
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	mutagenConfig "bunnyshell.com/dev/pkg/mutagen/config"
+)
+
+const (
+	mutagenListTemplate = "{{ json . }}"
+
+	mutagenStatusWatching = "watching"
+
+	syncPollInterval = time.Second
+
+	unlimitedProblems = -1
+
+	// problemsSummaryLength caps the problems listed in errors and warnings
+	problemsSummaryLength = 10
+
+	defaultFlushOnTerminateTimeout = 30 * time.Second
+)
+
+var (
+	ErrFlushTimeout    = fmt.Errorf("mutagen flush did not complete in time")
+	ErrTooManyProblems = fmt.Errorf("too many sync problems")
+)
+
+type MutagenSession struct {
+	Identifier   string            `json:"identifier"`
+	Name         string            `json:"name"`
+	CreationTime string            `json:"creationTime"`
+	Labels       map[string]string `json:"labels"`
+
+	Alpha MutagenEndpoint `json:"alpha"`
+	Beta  MutagenEndpoint `json:"beta"`
+
+	Paused           bool              `json:"paused"`
+	Status           string            `json:"status"`
+	LastError        string            `json:"lastError"`
+	SuccessfulCycles uint64            `json:"successfulCycles"`
+	Conflicts        []MutagenConflict `json:"conflicts"`
+}
+
+type MutagenEndpoint struct {
+	Path      string `json:"path"`
+	Connected bool   `json:"connected"`
+
+	Directories   uint64 `json:"directories"`
+	Files         uint64 `json:"files"`
+	SymbolicLinks uint64 `json:"symbolicLinks"`
+	TotalFileSize uint64 `json:"totalFileSize"`
+
+	ScanProblems       []MutagenProblem `json:"scanProblems"`
+	TransitionProblems []MutagenProblem `json:"transitionProblems"`
+
+	StagingProgress *MutagenStagingProgress `json:"stagingProgress"`
+}
+
+// MutagenProblem is a path mutagen failed to scan or to apply a change to
+type MutagenProblem struct {
+	Path  string `json:"path"`
+	Error string `json:"error"`
+}
+
+// MutagenStagingProgress sizes are those of the file at Path, TotalReceivedSize covers all the received files
+type MutagenStagingProgress struct {
+	Path              string `json:"path"`
+	ReceivedSize      uint64 `json:"receivedSize"`
+	ExpectedSize      uint64 `json:"expectedSize"`
+	ReceivedFiles     uint64 `json:"receivedFiles"`
+	ExpectedFiles     uint64 `json:"expectedFiles"`
+	TotalReceivedSize uint64 `json:"totalReceivedSize"`
+}
+
+// StagingFile is a file in flight, staged by the receiving endpoint
+type StagingFile struct {
+	Endpoint     string
+	Path         string
+	ReceivedSize uint64
+	ExpectedSize uint64
+}
+
+type MutagenConflict struct {
+	Root         string          `json:"root"`
+	AlphaChanges []MutagenChange `json:"alphaChanges"`
+	BetaChanges  []MutagenChange `json:"betaChanges"`
+}
+
+type MutagenChange struct {
+	Path string `json:"path"`
+}
+
+// Problems returns the scan and transition problems of both endpoints
+func (s *MutagenSession) Problems() []MutagenProblem {
+	problems := []MutagenProblem{}
+	for _, endpoint := range []MutagenEndpoint{s.Alpha, s.Beta} {
+		problems = append(problems, endpoint.ScanProblems...)
+		problems = append(problems, endpoint.TransitionProblems...)
+	}
+
+	return problems
+}
+
+// PendingChangesError is returned by SafeTerminate when terminating would drop unsynchronized changes:
+// conflicts, or changes still staged or applied when the flush returned, Progress describing them
+type PendingChangesError struct {
+	Conflicts []MutagenConflict
+	Progress  string
+}
+
+func (e *PendingChangesError) Error() string {
+	problems := []string{}
+
+	if len(e.Conflicts) > 0 {
+		roots := []string{}
+		for _, conflict := range e.Conflicts {
+			roots = append(roots, conflict.Root)
+		}
+		problems = append(problems, fmt.Sprintf("%d conflicting paths are not synchronized: %s", len(roots), strings.Join(roots, ", ")))
+	}
+
+	if e.Progress != "" {
+		problems = append(problems, fmt.Sprintf("changes are still being transferred: %s", e.Progress))
+	}
+
+	return strings.Join(problems, "; ")
+}
+
+func (s *MutagenSession) IsHealthy() bool {
+	return !s.Paused && s.LastError == ""
+}
+
+// hasPendingTransfers reports changes staged or being applied, which terminating the session would drop
+func (s *MutagenSession) hasPendingTransfers() bool {
+	switch s.Status {
+	case "staging-alpha", "staging-beta", "transitioning":
+		return true
+	}
+
+	return s.Alpha.StagingProgress != nil || s.Beta.StagingProgress != nil
+}
+
+// Progress summarizes the state and the staging progress of both endpoints
+func (s *MutagenSession) Progress() string {
+	progress := []string{fmt.Sprintf("status %s", s.Status)}
+
+	for name, endpoint := range map[string]MutagenEndpoint{"alpha": s.Alpha, "beta": s.Beta} {
+		if endpoint.StagingProgress == nil {
+			continue
+		}
+
+		staging := endpoint.StagingProgress
+		progress = append(progress, fmt.Sprintf(
+			"%s staging %d/%d files, %d/%d bytes",
+			name,
+			staging.ReceivedFiles,
+			staging.ExpectedFiles,
+			staging.ReceivedSize,
+			staging.ExpectedSize,
+		))
+	}
+	sort.Strings(progress[1:])
+
+	return strings.Join(progress, ", ")
+}
+
+// currentFile returns the file being staged, alpha first, nil when nothing is in flight
+func (s *MutagenSession) currentFile() *StagingFile {
+	for _, endpoint := range []struct {
+		name     string
+		endpoint MutagenEndpoint
+	}{{"alpha", s.Alpha}, {"beta", s.Beta}} {
+		staging := endpoint.endpoint.StagingProgress
+		if staging == nil || staging.Path == "" {
+			continue
+		}
+
+		return &StagingFile{
+			Endpoint:     endpoint.name,
+			Path:         staging.Path,
+			ReceivedSize: staging.ReceivedSize,
+			ExpectedSize: staging.ExpectedSize,
+		}
+	}
+
+	return nil
+}
+
+// SessionStartup describes how the mutagen session was brought up by Up
+type SessionStartup struct {
+	Reused   bool
+	Duration time.Duration
+
+	// TimeSaved compares an attach to the create duration recorded by the invocation which created the session
+	TimeSaved time.Duration
+}
+
+func (r *RemoteDevelopment) SessionStartup() SessionStartup {
+	return r.sessionStartup
+}
+
+// FlushSession blocks until mutagen completes a full synchronization cycle.
+// When ctx expires first the session is terminated and the last known progress is reported.
+func (r *RemoteDevelopment) FlushSession(ctx context.Context) error {
+	return r.flushMutagenSession(ctx, true)
+}
+
+// flushMutagenSession is FlushSession, leaving the session running on timeout unless terminateOnTimeout is set
+func (r *RemoteDevelopment) flushMutagenSession(ctx context.Context, terminateOnTimeout bool) error {
+	mutagenBinPath, err := r.getMutagenBinPath()
+	if err != nil {
+		return err
+	}
+
+	sessionName, err := r.getMutagenSessionName()
+	if err != nil {
+		return err
+	}
+
+	mutagenArgs := []string{
+		"sync",
+		"flush",
+		sessionName,
+	}
+
+	name, mutagenArgs := r.withPriority(mutagenBinPath, mutagenArgs)
+	mutagenCmd := exec.CommandContext(ctx, name, mutagenArgs...)
+	mutagenCmd.Env = r.getMutagenCommandEnv()
+
+	output, err := mutagenCmd.CombinedOutput()
+	r.logSync("flush: %v: %s", err, output)
+	if ctx.Err() != nil {
+		progress := "unknown"
+		if session, err := r.getMutagenSession(); err == nil && session != nil {
+			progress = session.Progress()
+		}
+
+		if !terminateOnTimeout {
+			return fmt.Errorf("%w: session %s left running, last progress: %s", ErrFlushTimeout, sessionName, progress)
+		}
+
+		r.terminateMutagenSession()
+
+		return fmt.Errorf("%w: session %s terminated, last progress: %s", ErrFlushTimeout, sessionName, progress)
+	}
+	if err != nil {
+		return fmt.Errorf("cannot flush mutagen session %s: %w: %s", sessionName, err, strings.TrimSpace(string(output)))
+	}
+
+	return nil
+}
+
+// flushBeforeTerminate pushes the last changes when WithFlushOnTerminate is set, teardown proceeds regardless
+func (r *RemoteDevelopment) flushBeforeTerminate() {
+	if r.flushOnTerminateTimeout <= 0 || r.syncMode == mutagenConfig.None {
+		return
+	}
+
+	if session, err := r.getMutagenSession(); err != nil || session == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.flushOnTerminateTimeout)
+	defer cancel()
+
+	if err := r.FlushSession(ctx); err != nil {
+		fmt.Printf("WARNING: final flush failed, the last changes may not be synchronized: %s\n", err)
+	}
+}
+
+// WaitForSync blocks until the initial synchronization completes and the session is watching for changes
+func (r *RemoteDevelopment) WaitForSync(ctx context.Context) error {
+	if r.syncMode == mutagenConfig.None {
+		return nil
+	}
+
+	ticker := time.NewTicker(syncPollInterval)
+	defer ticker.Stop()
+
+	for {
+		session, err := r.getMutagenSession()
+		if err != nil {
+			return err
+		}
+		if session == nil {
+			return fmt.Errorf("mutagen session not found")
+		}
+
+		r.logSessionStatus(session)
+		r.notifyConflicts(session.Conflicts)
+		r.transferStats.observe(session)
+		r.writeStatusFile(session)
+
+		if session.Status == mutagenStatusWatching && session.SuccessfulCycles > 0 {
+			if err := r.checkSyncProblems(session); err != nil {
+				return err
+			}
+
+			if err := r.finishInitialScan(); err != nil {
+				return err
+			}
+
+			r.notifySynced()
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *RemoteDevelopment) checkSyncProblems(session *MutagenSession) error {
+	problems := session.Problems()
+	if len(problems) == 0 {
+		return nil
+	}
+
+	summary := summarizeProblems(problems)
+	if r.maxAllowedProblems < 0 || len(problems) <= r.maxAllowedProblems {
+		fmt.Printf("WARNING: initial sync completed with %d problems: %s\n", len(problems), summary)
+		return nil
+	}
+
+	return fmt.Errorf("%w: %d, at most %d allowed: %s", ErrTooManyProblems, len(problems), r.maxAllowedProblems, summary)
+}
+
+func summarizeProblems(problems []MutagenProblem) string {
+	summary := []string{}
+	for _, problem := range problems[:min(len(problems), problemsSummaryLength)] {
+		summary = append(summary, fmt.Sprintf("%s: %s", problem.Path, problem.Error))
+	}
+
+	if len(problems) > problemsSummaryLength {
+		summary = append(summary, fmt.Sprintf("and %d more", len(problems)-problemsSummaryLength))
+	}
+
+	return strings.Join(summary, "; ")
+}
+
+func (r *RemoteDevelopment) notifySynced() {
+	r.onSyncedOnce.Do(func() {
+		if r.onSynced != nil {
+			r.onSynced()
+		}
+	})
+}
+
+// SafeTerminate flushes pending changes before terminating the session, within the WithFlushOnTerminate timeout
+// or 30s. Unless force is set, the session is left running when the flush fails or times out, returning
+// ErrFlushTimeout, or when conflicts or in-flight transfers remain.
+func (r *RemoteDevelopment) SafeTerminate(force bool) error {
+	if r.syncMode == mutagenConfig.None {
+		return nil
+	}
+
+	session, err := r.getMutagenSession()
+	if err != nil {
+		return err
+	}
+	if session == nil {
+		return r.removeSessionState()
+	}
+
+	timeout := r.flushOnTerminateTimeout
+	if timeout <= 0 {
+		timeout = defaultFlushOnTerminateTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := r.flushMutagenSession(ctx, false); err != nil && !force {
+		return err
+	}
+
+	session, err = r.getMutagenSession()
+	if err != nil {
+		return err
+	}
+	if session != nil && !force {
+		pendingChanges := &PendingChangesError{Conflicts: session.Conflicts}
+		if session.hasPendingTransfers() {
+			pendingChanges.Progress = session.Progress()
+		}
+
+		if len(pendingChanges.Conflicts) > 0 || pendingChanges.Progress != "" {
+			return pendingChanges
+		}
+	}
+
+	if err := r.terminateMutagenSession(); err != nil {
+		return err
+	}
+
+	return r.removeSessionState()
+}
+
+func (r *RemoteDevelopment) getMutagenSession() (*MutagenSession, error) {
+	sessionName, err := r.getMutagenSessionName()
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := r.listMutagenSessions()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range sessions {
+		if sessions[i].Name == sessionName {
+			return &sessions[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (r *RemoteDevelopment) listMutagenSessions() ([]MutagenSession, error) {
+	return r.queryMutagenSessions(nil)
+}
+
+func (r *RemoteDevelopment) queryMutagenSessions(env []string) ([]MutagenSession, error) {
+	mutagenBinPath, err := r.getMutagenBinPath()
+	if err != nil {
+		return nil, err
+	}
+
+	mutagenArgs := []string{
+		"sync",
+		"list",
+		"--template", mutagenListTemplate,
+	}
+
+	mutagenCmd := r.newMutagenCommand(mutagenBinPath, mutagenArgs...)
+	if env != nil {
+		mutagenCmd.Env = append(mutagenCmd.Environ(), env...)
+	}
+
+	output, err := mutagenCmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("cannot list mutagen sessions: %w", err)
+	}
+
+	return parseSyncList(output)
+}
+
+// parseSyncList decodes the output of "mutagen sync list --template '{{ json . }}'",
+// every session query goes through it. The template flag and the exported session models
+// are available in the pinned v0.15 line, see the recorded output in testdata/sync_list.
+func parseSyncList(output []byte) ([]MutagenSession, error) {
+	sessions := []MutagenSession{}
+	if strings.TrimSpace(string(output)) == "" {
+		return sessions, nil
+	}
+
+	if err := json.Unmarshal(output, &sessions); err != nil {
+		return nil, fmt.Errorf("cannot parse mutagen sessions: %w", err)
+	}
+
+	return sessions, nil
+}
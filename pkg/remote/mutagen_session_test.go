@@ -0,0 +1,161 @@
+package remote
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// the fixtures are recorded from "mutagen sync list --template '{{ json . }}'" with mutagen v0.15.0,
+// the sync list models are unchanged across the v0.15 releases
+func readSyncListFixture(t *testing.T, name string) []MutagenSession {
+	t.Helper()
+
+	output, err := os.ReadFile(filepath.Join("testdata", "sync_list", name+".json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sessions, err := parseSyncList(output)
+	if err != nil {
+		t.Fatalf("parseSyncList(%s): %v", name, err)
+	}
+
+	return sessions
+}
+
+func readSyncListFixtureSession(t *testing.T, name string) MutagenSession {
+	t.Helper()
+
+	sessions := readSyncListFixture(t, name)
+	if len(sessions) != 1 {
+		t.Fatalf("%s: got %d sessions, want 1", name, len(sessions))
+	}
+
+	return sessions[0]
+}
+
+func TestParseSyncListEmpty(t *testing.T) {
+	for _, output := range []string{"", "\n", "[]\n"} {
+		sessions, err := parseSyncList([]byte(output))
+		if err != nil {
+			t.Fatalf("parseSyncList(%q): %v", output, err)
+		}
+		if len(sessions) != 0 {
+			t.Errorf("parseSyncList(%q): got %d sessions, want 0", output, len(sessions))
+		}
+	}
+
+	if sessions := readSyncListFixture(t, "empty"); len(sessions) != 0 {
+		t.Errorf("got %d sessions, want 0", len(sessions))
+	}
+}
+
+func TestParseSyncListInvalid(t *testing.T) {
+	if _, err := parseSyncList([]byte("Name: remote-dev\n")); err == nil {
+		t.Error("expected an error for non JSON output")
+	}
+}
+
+func TestParseSyncListWatching(t *testing.T) {
+	session := readSyncListFixtureSession(t, "watching")
+
+	if session.Name != "remote-dev-fixture" || session.Identifier == "" || session.CreationTime == "" {
+		t.Errorf("unexpected session identity: %+v", session)
+	}
+	if session.Labels["app"] != "remote-dev" {
+		t.Errorf("got labels %v", session.Labels)
+	}
+	if session.Status != mutagenStatusWatching || session.Paused || !session.IsHealthy() {
+		t.Errorf("got status %q, paused %v, last error %q", session.Status, session.Paused, session.LastError)
+	}
+	if session.SuccessfulCycles != 2 {
+		t.Errorf("got %d successful cycles, want 2", session.SuccessfulCycles)
+	}
+
+	if !session.Alpha.Connected || !session.Beta.Connected {
+		t.Error("expected both endpoints connected")
+	}
+	if session.Alpha.Path != "/tmp/a" || session.Beta.Path != "/tmp/b" {
+		t.Errorf("got paths %q, %q", session.Alpha.Path, session.Beta.Path)
+	}
+	if session.Beta.Directories != 2 || session.Beta.Files != 2 || session.Beta.TotalFileSize != 13 {
+		t.Errorf("unexpected beta contents: %+v", session.Beta)
+	}
+
+	problems := session.Problems()
+	if len(problems) != 1 || problems[0].Path != "abs-link" || problems[0].Error == "" {
+		t.Errorf("got problems %+v", problems)
+	}
+}
+
+func TestParseSyncListPaused(t *testing.T) {
+	session := readSyncListFixtureSession(t, "paused")
+
+	if !session.Paused || session.IsHealthy() {
+		t.Errorf("expected a paused, unhealthy session, got paused %v", session.Paused)
+	}
+	if session.Status != "" {
+		t.Errorf("got status %q for a paused session, want none", session.Status)
+	}
+	if session.Alpha.Connected || session.Beta.Connected {
+		t.Error("expected both endpoints disconnected")
+	}
+}
+
+func TestParseSyncListConflicts(t *testing.T) {
+	session := readSyncListFixtureSession(t, "conflicts")
+
+	if len(session.Conflicts) != 1 {
+		t.Fatalf("got %d conflicts, want 1", len(session.Conflicts))
+	}
+
+	conflict := session.Conflicts[0]
+	if conflict.Root != "config.yaml" {
+		t.Errorf("got conflict root %q", conflict.Root)
+	}
+	if len(conflict.AlphaChanges) != 1 || conflict.AlphaChanges[0].Path != "config.yaml" {
+		t.Errorf("got alpha changes %+v", conflict.AlphaChanges)
+	}
+	if len(conflict.BetaChanges) != 1 || conflict.BetaChanges[0].Path != "config.yaml" {
+		t.Errorf("got beta changes %+v", conflict.BetaChanges)
+	}
+}
+
+func TestParseSyncListStaging(t *testing.T) {
+	session := readSyncListFixtureSession(t, "staging")
+
+	if session.Status != "staging-beta" {
+		t.Errorf("got status %q", session.Status)
+	}
+	if session.Alpha.StagingProgress != nil {
+		t.Errorf("expected no alpha staging, got %+v", session.Alpha.StagingProgress)
+	}
+
+	staging := session.Beta.StagingProgress
+	if staging == nil {
+		t.Fatal("expected beta staging progress")
+	}
+
+	want := MutagenStagingProgress{
+		Path:              "assets/blob3.bin",
+		ReceivedSize:      4128768,
+		ExpectedSize:      20000000,
+		ReceivedFiles:     1,
+		ExpectedFiles:     40,
+		TotalReceivedSize: 24128768,
+	}
+	if *staging != want {
+		t.Errorf("got staging progress %+v, want %+v", *staging, want)
+	}
+
+	wantFile := StagingFile{Endpoint: "beta", Path: "assets/blob3.bin", ReceivedSize: 4128768, ExpectedSize: 20000000}
+	if currentFile := session.currentFile(); currentFile == nil || *currentFile != wantFile {
+		t.Errorf("got current file %+v, want %+v", currentFile, wantFile)
+	}
+
+	wantProgress := "status staging-beta, beta staging 1/40 files, 4128768/20000000 bytes"
+	if progress := session.Progress(); progress != wantProgress {
+		t.Errorf("got progress %q, want %q", progress, wantProgress)
+	}
+}
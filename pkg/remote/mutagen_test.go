@@ -0,0 +1,144 @@
+package remote
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newFakeMutagen installs a mutagen stand-in recording the arguments of every call, one call per line.
+// It lists no sessions, so every session is created from scratch.
+func newFakeMutagen(t *testing.T, r *RemoteDevelopment) func() []string {
+	t.Helper()
+
+	return newScriptedFakeMutagen(t, r, "[]", "")
+}
+
+// newScriptedFakeMutagen lists sessionsJSON and runs flushScript on "sync flush"
+func newScriptedFakeMutagen(t *testing.T, r *RemoteDevelopment, sessionsJSON, flushScript string) func() []string {
+	t.Helper()
+
+	dir := t.TempDir()
+	callsFilePath := filepath.Join(dir, "calls")
+	script := fmt.Sprintf(
+		"#!/bin/sh\necho \"$*\" >> %q\ncase \"$1 $2\" in\n\"sync list\") echo %q ;;\n\"sync flush\") %s ;;\nesac\n",
+		callsFilePath,
+		sessionsJSON,
+		flushScript,
+	)
+
+	r.mutagenBinPath = filepath.Join(dir, "mutagen")
+	if err := os.WriteFile(r.mutagenBinPath, []byte(script), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	return func() []string {
+		data, err := os.ReadFile(callsFilePath)
+		if err != nil && !os.IsNotExist(err) {
+			t.Fatal(err)
+		}
+
+		return strings.Split(strings.TrimSpace(string(data)), "\n")
+	}
+}
+
+func TestTerminateMutagenSessionOnlyTerminates(t *testing.T) {
+	r := NewRemoteDevelopment().WithSessionName("rd-test")
+	calls := newFakeMutagen(t, r)
+
+	if err := r.terminateMutagenSession(); err != nil {
+		t.Fatal(err)
+	}
+
+	// no reset, flush or remote command: terminating must leave the content of both endpoints untouched
+	want := []string{"sync terminate rd-test"}
+	if got := calls(); !slices.Equal(got, want) {
+		t.Errorf("got calls %q, want %q", got, want)
+	}
+}
+
+func TestCreateMutagenSessionEndpointOrder(t *testing.T) {
+	localSyncPath := t.TempDir()
+	remoteEndpoint := "docker://app@api/app"
+
+	for _, test := range []struct {
+		reverse     bool
+		alpha, beta string
+	}{
+		{false, localSyncPath, remoteEndpoint},
+		{true, remoteEndpoint, localSyncPath},
+	} {
+		t.Run(fmt.Sprintf("reverse %t", test.reverse), func(t *testing.T) {
+			r := NewRemoteDevelopment().
+				WithSessionName("rd-test").
+				WithLocalSyncPath(localSyncPath).
+				WithRemoteSyncPath("/app").
+				WithDockerEndpoint("api", "app").
+				WithConfigFilePath(filepath.Join(t.TempDir(), "mutagen.yaml")).
+				WithReverse(test.reverse)
+			calls := newFakeMutagen(t, r)
+
+			if err := r.createMutagenSession(); err != nil {
+				t.Fatal(err)
+			}
+
+			var createArgs []string
+			for _, call := range calls() {
+				if strings.HasPrefix(call, "sync create ") {
+					createArgs = strings.Fields(call)
+				}
+			}
+			if len(createArgs) < 2 {
+				t.Fatalf("no sync create in %q", calls())
+			}
+
+			alpha, beta := createArgs[len(createArgs)-2], createArgs[len(createArgs)-1]
+			if alpha != test.alpha || beta != test.beta {
+				t.Errorf("got alpha %s, beta %s, want alpha %s, beta %s", alpha, beta, test.alpha, test.beta)
+			}
+		})
+	}
+}
+
+func TestSafeTerminateFlushTimeoutLeavesSessionRunning(t *testing.T) {
+	r := NewRemoteDevelopment().
+		WithSessionName("rd-test").
+		WithFlushOnTerminate(true, 100*time.Millisecond)
+	calls := newScriptedFakeMutagen(t, r, `[{"name":"rd-test","status":"watching"}]`, "exec sleep 10")
+
+	err := r.SafeTerminate(false)
+	if !errors.Is(err, ErrFlushTimeout) {
+		t.Fatalf("got %v, want ErrFlushTimeout", err)
+	}
+
+	for _, call := range calls() {
+		if strings.HasPrefix(call, "sync terminate") {
+			t.Errorf("the session was terminated after the flush timed out")
+		}
+	}
+}
+
+func TestSafeTerminateReportsPendingTransfers(t *testing.T) {
+	r := NewRemoteDevelopment().WithSessionName("rd-test")
+	sessions := `[{"name":"rd-test","status":"staging-beta","beta":{"stagingProgress":{"path":"blob.bin","receivedFiles":1,"expectedFiles":4}}}]`
+	calls := newScriptedFakeMutagen(t, r, sessions, "true")
+
+	pendingChanges := &PendingChangesError{}
+	if err := r.SafeTerminate(false); !errors.As(err, &pendingChanges) {
+		t.Fatalf("got %v, want a PendingChangesError", err)
+	}
+	if pendingChanges.Progress == "" {
+		t.Errorf("got no progress for the staged changes")
+	}
+
+	for _, call := range calls() {
+		if strings.HasPrefix(call, "sync terminate") {
+			t.Errorf("the session was terminated with changes in flight")
+		}
+	}
+}
@@ -3,3 +3,8 @@ package remote
 func getMutagenBinFilename() string {
 	return mutagenBinFilename + ".exe"
 }
+
+// ensureMutagenBinExecutable is a no-op, windows relies on the file extension
+func ensureMutagenBinExecutable(mutagenBinPath string) error {
+	return nil
+}
@@ -0,0 +1,111 @@
+package remote
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	mutagenConfig "bunnyshell.com/dev/pkg/mutagen/config"
+	bunnyshellSSH "bunnyshell.com/dev/pkg/ssh"
+)
+
+const (
+	// remoteMarkerPathPattern lives outside of the synced tree, in the container filesystem lost on replacement
+	remoteMarkerPathPattern = "/tmp/.remote-dev-%s"
+
+	podReplacementCheckInterval = 30 * time.Second
+)
+
+// recordRemoteIdentity leaves a random marker in the remote container and remembers it with the hostname,
+// a fresh container has neither. Detection is disabled with a warning when the marker cannot be written.
+func (r *RemoteDevelopment) recordRemoteIdentity() {
+	if r.syncMode == mutagenConfig.None {
+		return
+	}
+
+	token := make([]byte, 8)
+	if _, err := rand.Read(token); err != nil {
+		return
+	}
+
+	markerPath, err := r.getRemoteMarkerPath()
+	if err != nil {
+		return
+	}
+
+	output, err := r.runRemoteCommandOutput(fmt.Sprintf(
+		"echo %s > %s && hostname",
+		hex.EncodeToString(token),
+		bunnyshellSSH.QuoteArg(markerPath),
+	))
+	if err != nil {
+		fmt.Printf("WARNING: pod replacement detection disabled, cannot write %s: %s\n", markerPath, err)
+		return
+	}
+
+	r.remoteIdentity = hex.EncodeToString(token) + "\n" + strings.TrimSpace(string(output))
+}
+
+func (r *RemoteDevelopment) getRemoteMarkerPath() (string, error) {
+	sessionKey, err := r.getMutagenSessionKey()
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf(remoteMarkerPathPattern, sessionKey), nil
+}
+
+// isRemoteReplaced compares the marker and hostname with the recorded ones, on stdout only as SSH warnings would differ.
+// The port-forward is bound to the pod it was opened for, when it cannot connect the pod is resolved again
+// and the forward re-established first. A remote still unreachable is not reported as replaced,
+// mutagen reports the disconnection on its own.
+func (r *RemoteDevelopment) isRemoteReplaced() bool {
+	if r.remoteIdentity == "" {
+		return false
+	}
+
+	markerPath, err := r.getRemoteMarkerPath()
+	if err != nil {
+		return false
+	}
+
+	command := fmt.Sprintf("cat %s 2>/dev/null; hostname", bunnyshellSSH.QuoteArg(markerPath))
+	output, err := r.runRemoteCommandOutput(command)
+	if errors.Is(err, bunnyshellSSH.ErrConnectionFailed) && r.dockerEndpoint == nil {
+		if err := r.reconnectSSHPortForward(); err != nil {
+			return false
+		}
+
+		output, err = r.runRemoteCommandOutput(command)
+	}
+	if err != nil {
+		return false
+	}
+
+	return strings.TrimSpace(string(output)) != r.remoteIdentity
+}
+
+// handleRemoteReplacement resets the session for a full re-sync to a new pod or container,
+// the content synced to the previous one being gone
+func (r *RemoteDevelopment) handleRemoteReplacement() error {
+	if !r.isRemoteReplaced() {
+		return nil
+	}
+
+	fmt.Printf("INFO: the remote container was replaced, re-synchronizing\n")
+
+	if r.onPodReplaced != nil {
+		r.onPodReplaced()
+	}
+
+	r.recordRemoteIdentity()
+
+	if err := r.ensureRemoteSyncPath(); err != nil {
+		return err
+	}
+
+	return r.resetMutagenSession()
+}
@@ -17,8 +17,8 @@ const (
 )
 
 func (r *RemoteDevelopment) ensureRemoteSSHPortForward() error {
-	r.StartSpinner(" Start Remote SSH Port Forward")
-	defer r.spinner.Stop()
+	r.startStep(StepStartSSHPortForward)
+	defer r.StopSpinner()
 
 	remoteDevPod, err := r.getRemoteDevPod()
 	if err != nil {
@@ -35,6 +35,28 @@ func (r *RemoteDevelopment) ensureRemoteSSHPortForward() error {
 	return nil
 }
 
+// reconnectSSHPortForward forwards to the pod currently running the resource, e.g. after the previous one was rescheduled.
+// The local port is kept, so the SSH config, the tunnels and the mutagen session URL remain valid.
+func (r *RemoteDevelopment) reconnectSSHPortForward() error {
+	remoteDevPod, err := r.getRemoteDevPod()
+	if err != nil {
+		return err
+	}
+
+	if r.sshPortForwarder != nil {
+		r.sshPortForwarder.Close()
+	}
+
+	r.sshPortForwardOptions = k8s.NewPortForwardOptions(SSHPortForwardInterface, SSHPortForwardRemotePort, r.sshPortForwardOptions.LocalPort)
+	forwarder, err := r.kubernetesClient.PortForward(remoteDevPod, r.sshPortForwardOptions)
+	if err != nil {
+		return err
+	}
+	r.sshPortForwarder = forwarder
+
+	return nil
+}
+
 func (r *RemoteDevelopment) startSSHTunnels() error {
 	for i := range r.sshTunnels {
 		serverEndpoint := ssh.NewEndpoint(r.sshPortForwardOptions.Interface, r.sshPortForwardOptions.LocalPort)
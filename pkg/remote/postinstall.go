@@ -0,0 +1,49 @@
+package remote
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+const (
+	xattrBinFilename = "xattr"
+	quarantineXattr  = "com.apple.quarantine"
+)
+
+// runMutagenPostInstall prepares a freshly installed binary to be executed, best-effort: failures are only reported
+// since the binary may run regardless, e.g. when Gatekeeper is disabled
+func (r *RemoteDevelopment) runMutagenPostInstall(mutagenBinPath string) {
+	if runtime.GOOS == "darwin" {
+		if err := removeQuarantine(mutagenBinPath); err != nil {
+			fmt.Printf("WARNING: cannot remove the quarantine attribute of %s, macOS may refuse to run it: %s\n", mutagenBinPath, err)
+		}
+	}
+
+	if len(r.postInstallCommand) == 0 {
+		return
+	}
+
+	args := append(slices.Clone(r.postInstallCommand[1:]), mutagenBinPath)
+	output, err := exec.Command(r.postInstallCommand[0], args...).CombinedOutput()
+	if err != nil {
+		fmt.Printf("WARNING: post-install command %s failed: %s: %s\n", r.postInstallCommand[0], err, strings.TrimSpace(string(output)))
+	}
+}
+
+// removeQuarantine lifts the Gatekeeper quarantine, behind "cannot be opened" errors on first run
+func removeQuarantine(path string) error {
+	// no attribute, nothing to remove
+	if err := exec.Command(xattrBinFilename, "-p", quarantineXattr, path).Run(); err != nil {
+		return nil
+	}
+
+	output, err := exec.Command(xattrBinFilename, "-d", quarantineXattr, path).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(output)))
+	}
+
+	return nil
+}
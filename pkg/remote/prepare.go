@@ -0,0 +1,47 @@
+package remote
+
+import (
+	"context"
+	"errors"
+
+	mutagenConfig "bunnyshell.com/dev/pkg/mutagen/config"
+)
+
+// Prepare front-loads the network-bound part of Up, provisioning the mutagen binary without creating a session.
+// The config file is written too once a resource is selected, it depends on the session key.
+// Cancelling ctx aborts the download.
+func (r *RemoteDevelopment) Prepare(ctx context.Context) error {
+	if r.syncMode == mutagenConfig.None {
+		return nil
+	}
+
+	r.downloadContext = ctx
+	defer func() {
+		r.downloadContext = nil
+	}()
+
+	r.startStep(StepSetupMutagen)
+	defer r.StopSpinner()
+
+	if err := r.ensureMutagenBin(); err != nil {
+		if ctx.Err() != nil {
+			return errors.Join(ctx.Err(), err)
+		}
+
+		return err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if r.dockerEndpoint == nil {
+		if resource, err := r.getResource(); err != nil || resource == nil {
+			return nil
+		}
+	}
+
+	removeLegacyMutagenConfigFiles()
+
+	return r.ensureMutagenConfigFile()
+}
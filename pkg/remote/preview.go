@@ -0,0 +1,219 @@
+package remote
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	mutagenConfig "bunnyshell.com/dev/pkg/mutagen/config"
+	bunnyshellSSH "bunnyshell.com/dev/pkg/ssh"
+)
+
+// SyncPreview lists the differences between the local and the remote sync paths
+type SyncPreview struct {
+	// LocalOnly files are created on the remote
+	LocalOnly []string
+	// RemoteOnly files are created locally in two-way modes, deleted from the remote in one-way-replica mode
+	RemoteOnly []string
+	// Modified files differ in content, the winner depends on the sync mode
+	Modified []string
+}
+
+func (p *SyncPreview) IsEmpty() bool {
+	return len(p.LocalOnly) == 0 && len(p.RemoteOnly) == 0 && len(p.Modified) == 0
+}
+
+func (p *SyncPreview) String() string {
+	if p.IsEmpty() {
+		return "local and remote are in sync"
+	}
+
+	lines := []string{}
+	for _, filePath := range p.LocalOnly {
+		lines = append(lines, "+ local  "+filePath)
+	}
+	for _, filePath := range p.RemoteOnly {
+		lines = append(lines, "+ remote "+filePath)
+	}
+	for _, filePath := range p.Modified {
+		lines = append(lines, "~ both   "+filePath)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// PreviewSync compares file checksums on both sides without syncing anything, mutagen has no dry-run mode.
+// It needs the SSH endpoint, so call it after the remote is prepared and before the session starts.
+// Ignores are approximated: plain and "/"-anchored glob patterns are honoured, negations are not.
+func (r *RemoteDevelopment) PreviewSync() (*SyncPreview, error) {
+	if r.syncMode == mutagenConfig.None {
+		return &SyncPreview{}, nil
+	}
+
+	ignore, err := r.getMutagenIgnore()
+	if err != nil {
+		return nil, err
+	}
+	ignores := append([]string{".git"}, ignore.Paths...)
+
+	localChecksums, err := getLocalChecksums(r.localSyncPath, ignores)
+	if err != nil {
+		return nil, err
+	}
+
+	remoteChecksums, err := r.getRemoteChecksums(ignores)
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &SyncPreview{}
+	for filePath, checksum := range localChecksums {
+		remoteChecksum, ok := remoteChecksums[filePath]
+		if !ok {
+			preview.LocalOnly = append(preview.LocalOnly, filePath)
+		} else if remoteChecksum != checksum {
+			preview.Modified = append(preview.Modified, filePath)
+		}
+	}
+	for filePath := range remoteChecksums {
+		if _, ok := localChecksums[filePath]; !ok {
+			preview.RemoteOnly = append(preview.RemoteOnly, filePath)
+		}
+	}
+
+	sort.Strings(preview.LocalOnly)
+	sort.Strings(preview.RemoteOnly)
+	sort.Strings(preview.Modified)
+
+	return preview, nil
+}
+
+func getLocalChecksums(root string, ignores []string) (map[string]string, error) {
+	checksums := make(map[string]string)
+
+	err := walkLocalFiles(root, ignores, func(relativePath, filePath string) error {
+		checksum, err := getFileChecksum(filePath)
+		if err != nil {
+			return err
+		}
+		checksums[relativePath] = checksum
+
+		return nil
+	})
+
+	return checksums, err
+}
+
+// walkLocalFiles calls fn for the regular files of root which are not ignored, with their slash separated relative path
+func walkLocalFiles(root string, ignores []string, fn func(relativePath, filePath string) error) error {
+	return filepath.WalkDir(root, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relativePath, err := filepath.Rel(root, filePath)
+		if err != nil {
+			return err
+		}
+		relativePath = filepath.ToSlash(relativePath)
+		if relativePath == "." {
+			return nil
+		}
+
+		if isIgnoredPath(relativePath, ignores) {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+
+		return fn(relativePath, filePath)
+	})
+}
+
+func getFileChecksum(filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := md5.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func (r *RemoteDevelopment) getRemoteChecksums(ignores []string) (map[string]string, error) {
+	command := fmt.Sprintf(
+		"cd %s && find . -type f -exec md5sum {} +",
+		bunnyshellSSH.QuoteArg(r.remoteSyncPath),
+	)
+
+	output, err := r.runRemoteCommand(command)
+	if err != nil {
+		return nil, fmt.Errorf("cannot list remote files in %s: %w: %s", r.remoteSyncPath, err, strings.TrimSpace(string(output)))
+	}
+
+	checksums := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		checksum, filePath, found := strings.Cut(scanner.Text(), "  ")
+		if !found {
+			continue
+		}
+
+		filePath = strings.TrimPrefix(filePath, "./")
+		if isIgnoredPath(filePath, ignores) {
+			continue
+		}
+
+		checksums[filePath] = checksum
+	}
+
+	return checksums, scanner.Err()
+}
+
+func isIgnoredPath(relativePath string, ignores []string) bool {
+	components := strings.Split(relativePath, "/")
+
+	for _, pattern := range ignores {
+		if strings.HasPrefix(pattern, "!") {
+			continue
+		}
+
+		if strings.HasPrefix(pattern, "/") {
+			pattern = strings.TrimSuffix(strings.TrimPrefix(pattern, "/"), "/")
+			for i := range components {
+				if matched, _ := path.Match(pattern, strings.Join(components[:i+1], "/")); matched {
+					return true
+				}
+			}
+			continue
+		}
+
+		pattern = strings.TrimSuffix(pattern, "/")
+		for _, component := range components {
+			if matched, _ := path.Match(pattern, component); matched {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,39 @@
+//go:build !windows
+// +build !windows
+
+package remote
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strconv"
+)
+
+const (
+	niceBinFilename   = "nice"
+	ioniceBinFilename = "ionice"
+
+	// best-effort class at its lowest level, the idle class could starve the sync entirely
+	ioniceBestEffortClass = "2"
+	ioniceLowestLevel     = "7"
+)
+
+// getPriorityCommandPrefix lowers the CPU priority through nice and, on linux, the disk priority through ionice
+func getPriorityCommandPrefix(niceness int) ([]string, error) {
+	niceBinPath, err := exec.LookPath(niceBinFilename)
+	if err != nil {
+		return nil, fmt.Errorf("cannot lower the mutagen priority: %w", err)
+	}
+
+	prefix := []string{niceBinPath, "-n", strconv.Itoa(niceness)}
+
+	// ionice is part of util-linux, missing on minimal systems
+	if runtime.GOOS == "linux" {
+		if ioniceBinPath, err := exec.LookPath(ioniceBinFilename); err == nil {
+			prefix = append(prefix, ioniceBinPath, "-c", ioniceBestEffortClass, "-n", ioniceLowestLevel)
+		}
+	}
+
+	return prefix, nil
+}
@@ -0,0 +1,9 @@
+package remote
+
+import (
+	"fmt"
+)
+
+func getPriorityCommandPrefix(niceness int) ([]string, error) {
+	return nil, fmt.Errorf("cannot lower the mutagen priority: not supported on windows")
+}
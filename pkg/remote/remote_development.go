@@ -1,9 +1,14 @@
 package remote
 
 import (
+	"context"
 	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
 	"regexp"
 	"strconv"
+	"sync"
 	"time"
 
 	"bunnyshell.com/dev/pkg/k8s"
@@ -18,6 +23,9 @@ import (
 	"k8s.io/client-go/tools/portforward"
 )
 
+// SessionNamer replaces the default hashed mutagen session name
+type SessionNamer func(r *RemoteDevelopment) string
+
 // +enum
 type ResourceType string
 
@@ -35,8 +43,14 @@ type RemoteDevelopment struct {
 
 	sshPrivateKeyPath string
 	sshPublicKeyPath  string
+	sshOptions        *SSHOptions
+
+	dockerEndpoint *DockerEndpoint
+	remoteShell    string
 
-	spinner *spinner.Spinner
+	spinner      *spinner.Spinner
+	spinnerMutex sync.Mutex
+	spinnerUsers int
 
 	kubernetesClient      *k8s.KubernetesClient
 	sshPortForwardOptions *k8s.PortForwardOptions
@@ -52,10 +66,119 @@ type RemoteDevelopment struct {
 	container    *coreV1.Container
 
 	syncMode       mutagenConfig.Mode
+	scanMode       mutagenConfig.ScanMode
 	localSyncPath  string
+	localSyncFile  string
 	remoteSyncPath string
+	reverse        bool
+
+	createRemoteSyncPath bool
+	remoteSyncPathMode   os.FileMode
+	remoteSyncPathOwner  string
+	verifyRemoteSyncPath bool
+
+	inspectRemoteSyncPathLayout bool
+
+	checkCaseConflicts bool
+
+	sessionNamer      SessionNamer
+	sessionNamePrefix string
+	sessionName       string
+	sessionScope      string
+
+	reuseSession   bool
+	sessionStartup SessionStartup
+
+	bundledMutagenBinPath string
+	preferSystemMutagen   bool
+	mutagenBinPath        string
+	downloadHeaders       http.Header
+	caBundlePath          string
+	mutagenSetup          MutagenSetup
+	mutagenProvision      *MutagenProvision
+
+	restartIncompatibleDaemon  bool
+	disableDownloadCompression bool
+	disableDownload            bool
+	tempDir                    string
+
+	binFileMode    os.FileMode
+	configFileMode os.FileMode
+
+	configFilePath string
+
+	postInstallCommand []string
+
+	extraCreateArgs []string
+	mutagenEnv      []string
+
+	priority            int
+	priorityWarningOnce sync.Once
+
+	createAttempts     int
+	createRetryBackoff time.Duration
+
+	recoveryGracePeriod time.Duration
+	unhealthySince      time.Time
+
+	ignoreGroups          map[mutagenConfig.IgnoreGroup]bool
+	customIgnores         []string
+	largeBinaryExtensions []string
+	useGitignore          bool
+
+	initialScanIgnores []string
+	initialScanPhase   bool
+
+	ignoreOlderThan time.Duration
+	staleFiles      []string
+
+	onSynced     func()
+	onSyncedOnce sync.Once
+
+	onConflict        func([]MutagenConflict)
+	reportedConflicts map[string]bool
+	conflictsMutex    sync.Mutex
+
+	maxAllowedProblems int
+
+	transferStats    transferStats
+	sessionStartedAt time.Time
+
+	logLatency bool
+
+	syncLog *syncLog
+
+	flushOnTerminateTimeout time.Duration
+
+	syncDebounce time.Duration
+
+	onDownloaded       func(path, version, checksum string)
+	downloadBufferSize int
+	downloadContext    context.Context
+
+	syncWorkingDirByDefault bool
+
+	metadataDir string
+
+	remoteIdentity string
+	onPodReplaced  func()
+
+	configDriftPolicy   ConfigDriftPolicy
+	configDriftWarnOnce sync.Once
+
+	statusFileEnabled bool
+	lastSyncCycles    uint64
+	lastSyncTime      time.Time
+
+	debugDaemonEnabled bool
+	debugDaemon        *debugDaemon
+
+	stepLabels map[Step]string
+
+	purgeRemoteOnAbort bool
 
 	stopChannel chan bool
+	stopOnce    sync.Once
 
 	startedAt   int64
 	waitTimeout int64
@@ -72,6 +195,22 @@ func NewRemoteDevelopment() *RemoteDevelopment {
 		syncMode:    mutagenConfig.TwoWayResolved,
 		startedAt:   time.Now().Unix(),
 		waitTimeout: 120,
+
+		binFileMode:    defaultMutagenBinFileMode,
+		configFileMode: 0600,
+
+		checkCaseConflicts: hasCaseInsensitiveFilesystem(),
+
+		createAttempts:     defaultCreateAttempts,
+		createRetryBackoff: defaultCreateRetryBackoff,
+
+		recoveryGracePeriod: defaultRecoveryGracePeriod,
+
+		sessionNamePrefix: defaultSessionNamePrefix,
+
+		maxAllowedProblems: unlimitedProblems,
+
+		ignoreGroups: newDefaultIgnoreGroups(),
 	}
 }
 
@@ -80,22 +219,451 @@ func (r *RemoteDevelopment) WithSyncMode(syncMode mutagenConfig.Mode) *RemoteDev
 	return r
 }
 
+// WithScanMode overrides mutagen's scan mode, left empty mutagen picks accelerated
+func (r *RemoteDevelopment) WithScanMode(scanMode mutagenConfig.ScanMode) *RemoteDevelopment {
+	r.scanMode = scanMode
+	return r
+}
+
+// WithFastScan selects accelerated scans, which rescan only the paths reported by the filesystem watcher.
+// It trades memory for wall-clock time on large trees, the initial scan is a full scan regardless.
+// Disabling it forces full scans, slower but robust on filesystems with unreliable watch events.
+func (r *RemoteDevelopment) WithFastScan(fastScan bool) *RemoteDevelopment {
+	if fastScan {
+		return r.WithScanMode(mutagenConfig.ScanModeAccelerated)
+	}
+
+	return r.WithScanMode(mutagenConfig.ScanModeFull)
+}
+
+// WithLocalSyncFile syncs only filePath: mutagen syncs directories, so its parent directory is synced
+// with every other entry ignored, see SingleFileIgnores
+func (r *RemoteDevelopment) WithLocalSyncFile(filePath string) *RemoteDevelopment {
+	r.WithLocalSyncPath(filepath.Dir(filePath))
+	r.localSyncFile = filepath.Base(filePath)
+
+	return r
+}
+
+// WithLocalSyncPath resolves a relative localSyncPath against the working directory right away,
+// so errors and the persisted state always name the directory actually synced
 func (r *RemoteDevelopment) WithLocalSyncPath(localSyncPath string) *RemoteDevelopment {
+	if absoluteSyncPath, err := filepath.Abs(localSyncPath); err == nil && localSyncPath != "" {
+		localSyncPath = absoluteSyncPath
+	}
+
 	r.localSyncPath = localSyncPath
 	return r
 }
 
+// LocalSyncPath returns the absolute local sync path
+func (r *RemoteDevelopment) LocalSyncPath() string {
+	return r.localSyncPath
+}
+
 func (r *RemoteDevelopment) WithRemoteSyncPath(remoteSyncPath string) *RemoteDevelopment {
 	r.remoteSyncPath = remoteSyncPath
 	return r
 }
 
+// WithReverse makes the remote the alpha endpoint, so one-way modes sync from the remote to the local path
+// and the remote wins conflicts in two-way-resolved mode. Replica sessions always sync from the local path.
+func (r *RemoteDevelopment) WithReverse(reverse bool) *RemoteDevelopment {
+	r.reverse = reverse
+	return r
+}
+
+// WithCreateRemoteSyncPath pre-creates the remote sync path over SSH before the mutagen session starts.
+// A zero mode leaves the permissions to the container's umask, an empty owner leaves the ownership unchanged.
+func (r *RemoteDevelopment) WithCreateRemoteSyncPath(mode os.FileMode, owner string) *RemoteDevelopment {
+	r.createRemoteSyncPath = true
+	r.remoteSyncPathMode = mode
+	r.remoteSyncPathOwner = owner
+	return r
+}
+
+// WithVerifyRemoteSyncPath checks over SSH that the container user can write to the remote sync path
+func (r *RemoteDevelopment) WithVerifyRemoteSyncPath(verifyRemoteSyncPath bool) *RemoteDevelopment {
+	r.verifyRemoteSyncPath = verifyRemoteSyncPath
+	return r
+}
+
+// WithRemoteSyncPathInspection warns before syncing when the remote sync path is a symlink loop, resolves to /
+// or lives on a network mount, layouts which make mutagen scan far more than intended
+func (r *RemoteDevelopment) WithRemoteSyncPathInspection(inspectRemoteSyncPathLayout bool) *RemoteDevelopment {
+	r.inspectRemoteSyncPathLayout = inspectRemoteSyncPathLayout
+	return r
+}
+
+// WithCaseConflictCheck refuses to sync remote paths which differ only in case.
+// Mutagen probes case sensitivity on its own and has no setting for it, such paths end up as sync problems
+// on a case-insensitive local filesystem. Enabled by default on darwin and windows.
+func (r *RemoteDevelopment) WithCaseConflictCheck(checkCaseConflicts bool) *RemoteDevelopment {
+	r.checkCaseConflicts = checkCaseConflicts
+	return r
+}
+
+// WithSessionName fixes the mutagen session name, e.g. for integration tests, bypassing the namer and the prefix.
+// The name must meet mutagen's constraints, see ErrInvalidSessionName. Unlike the name derived from the session key,
+// a fixed name gives no collision avoidance: two deployments using it share one session, the second refused
+// by the ownership check. TerminateSessionsOlderThan only considers prefixed names, it skips such a session.
+func (r *RemoteDevelopment) WithSessionName(sessionName string) *RemoteDevelopment {
+	r.sessionName = sessionName
+	return r
+}
+
+func (r *RemoteDevelopment) WithSessionNamer(sessionNamer SessionNamer) *RemoteDevelopment {
+	r.sessionNamer = sessionNamer
+	return r
+}
+
+// WithSessionNamePrefix replaces the "rd-" prefix of the default session names, e.g. to tell apart the sessions
+// of tools embedding this package. Sessions created under a previous prefix are not found anymore.
+func (r *RemoteDevelopment) WithSessionNamePrefix(sessionNamePrefix string) *RemoteDevelopment {
+	r.sessionNamePrefix = sessionNamePrefix
+	return r
+}
+
+// WithSessionScope isolates sessions of the same deployment, e.g. per git branch.
+// Changing the scope creates a new session, the previous one is left untouched.
+func (r *RemoteDevelopment) WithSessionScope(sessionScope string) *RemoteDevelopment {
+	r.sessionScope = sessionScope
+	return r
+}
+
+// WithReuseSession keeps the mutagen session running on exit and attaches to it on the next start,
+// skipping the initial full scan when the session is still healthy
+func (r *RemoteDevelopment) WithReuseSession(reuseSession bool) *RemoteDevelopment {
+	r.reuseSession = reuseSession
+	return r
+}
+
+// WithBundledMutagenBin installs mutagen from a binary shipped with the tool instead of downloading it
+func (r *RemoteDevelopment) WithBundledMutagenBin(bundledMutagenBinPath string) *RemoteDevelopment {
+	r.bundledMutagenBinPath = bundledMutagenBinPath
+	return r
+}
+
+// WithPreferSystemMutagen uses a compatible mutagen found on PATH instead of provisioning one
+func (r *RemoteDevelopment) WithPreferSystemMutagen(preferSystemMutagen bool) *RemoteDevelopment {
+	r.preferSystemMutagen = preferSystemMutagen
+	return r
+}
+
+// WithRestartIncompatibleDaemon restarts a running mutagen daemon of another version with our binary,
+// instead of failing. Sessions of the other mutagen install are resumed by our daemon.
+func (r *RemoteDevelopment) WithRestartIncompatibleDaemon(restartIncompatibleDaemon bool) *RemoteDevelopment {
+	r.restartIncompatibleDaemon = restartIncompatibleDaemon
+	return r
+}
+
+// WithDownloadHeader adds a request header to the mutagen download, e.g. for artifact mirrors requiring authentication
+func (r *RemoteDevelopment) WithDownloadHeader(name, value string) *RemoteDevelopment {
+	if r.downloadHeaders == nil {
+		r.downloadHeaders = http.Header{}
+	}
+
+	r.downloadHeaders.Add(name, value)
+	return r
+}
+
+// WithDownloadCompression toggles the Accept-Encoding negotiation of the mutagen download, enabled by default
+func (r *RemoteDevelopment) WithDownloadCompression(enabled bool) *RemoteDevelopment {
+	r.disableDownloadCompression = !enabled
+	return r
+}
+
+// WithDisableDownload guarantees no outbound connection for mutagen: Up fails with ErrDownloadDisabled unless
+// the binary is already installed, cached, bundled or on the PATH. Also enabled by the BNS_MUTAGEN_OFFLINE variable.
+func (r *RemoteDevelopment) WithDisableDownload(disableDownload bool) *RemoteDevelopment {
+	r.disableDownload = disableDownload
+	return r
+}
+
+// WithTempDir downloads and extracts mutagen in tempDir, e.g. when the workspace is on a small partition.
+// Defaults to the BNS_TMPDIR environment variable, then to the destination dir.
+func (r *RemoteDevelopment) WithTempDir(tempDir string) *RemoteDevelopment {
+	r.tempDir = tempDir
+	return r
+}
+
+// WithCABundle trusts the PEM certificates of caBundlePath for the mutagen download, on top of the system roots.
+// Defaults to the BNS_CA_BUNDLE environment variable.
+func (r *RemoteDevelopment) WithCABundle(caBundlePath string) *RemoteDevelopment {
+	r.caBundlePath = caBundlePath
+	return r
+}
+
+// WithFileModes sets the permissions applied to the extracted mutagen binary and the generated mutagen config,
+// regardless of the umask and the archive's file modes
+func (r *RemoteDevelopment) WithFileModes(binFileMode, configFileMode os.FileMode) *RemoteDevelopment {
+	r.binFileMode = binFileMode
+	r.configFileMode = configFileMode
+	return r
+}
+
+// WithConfigFilePath writes the mutagen config to configFilePath instead of the per-session file of the workspace,
+// e.g. a project-local .bunnyshell/ folder. Missing parent directories are created.
+func (r *RemoteDevelopment) WithConfigFilePath(configFilePath string) *RemoteDevelopment {
+	r.configFilePath = configFilePath
+	return r
+}
+
+// WithPostInstallCommand runs a command, e.g. codesign, on each newly installed mutagen binary, its path appended
+// as the last argument. Failures are reported as warnings.
+func (r *RemoteDevelopment) WithPostInstallCommand(name string, args ...string) *RemoteDevelopment {
+	r.postInstallCommand = append([]string{name}, args...)
+	return r
+}
+
+// WithExtraCreateArgs is an escape hatch passing raw flags to "mutagen sync create" for options not otherwise exposed.
+// The flags are not interpreted, the session name and configuration flags are reserved.
+func (r *RemoteDevelopment) WithExtraCreateArgs(args ...string) *RemoteDevelopment {
+	r.extraCreateArgs = append(r.extraCreateArgs, args...)
+	return r
+}
+
+// WithPriority runs mutagen with a nice increment, 1 to 19, so the initial scans don't starve interactive work.
+// The daemon keeps the priority it was started with. Unsupported on windows, where a warning is logged.
+func (r *RemoteDevelopment) WithPriority(niceness int) *RemoteDevelopment {
+	r.priority = niceness
+	return r
+}
+
+// WithMutagenEnv sets an environment variable for the mutagen commands, e.g. SSH_AUTH_SOCK for a non-default agent.
+// It overrides the inherited value of the same variable.
+func (r *RemoteDevelopment) WithMutagenEnv(name, value string) *RemoteDevelopment {
+	r.mutagenEnv = append(r.mutagenEnv, fmt.Sprintf("%s=%s", name, value))
+	return r
+}
+
+// WithCreateRetry retries the session creation on connection failures, e.g. while the pod's SSH server starts.
+// The backoff doubles after each attempt, attempts below 1 are treated as 1.
+func (r *RemoteDevelopment) WithCreateRetry(attempts int, backoff time.Duration) *RemoteDevelopment {
+	r.createAttempts = max(attempts, 1)
+	r.createRetryBackoff = backoff
+	return r
+}
+
+// WithRecoveryGracePeriod sets how long WatchAndRestart tolerates a missing or errored session before
+// resetting or recreating it, 0 recovers on the first observation
+func (r *RemoteDevelopment) WithRecoveryGracePeriod(recoveryGracePeriod time.Duration) *RemoteDevelopment {
+	r.recoveryGracePeriod = recoveryGracePeriod
+	return r
+}
+
+// WithOnConflict registers a callback fired by WatchAndRestart and WaitForSync with the newly detected conflicts,
+// each conflict being reported once until resolved
+func (r *RemoteDevelopment) WithOnConflict(onConflict func([]MutagenConflict)) *RemoteDevelopment {
+	r.onConflict = onConflict
+	return r
+}
+
+// WithOnPodReplaced registers a callback fired by WatchAndRestart when the remote container is found replaced,
+// e.g. after the pod was rescheduled, right before the session is reset for a full re-sync
+func (r *RemoteDevelopment) WithOnPodReplaced(onPodReplaced func()) *RemoteDevelopment {
+	r.onPodReplaced = onPodReplaced
+	return r
+}
+
+// WithOnSynced registers a callback fired once, when the initial sync completes
+func (r *RemoteDevelopment) WithOnSynced(onSynced func()) *RemoteDevelopment {
+	r.onSynced = onSynced
+	return r
+}
+
+// WithMaxAllowedProblems makes WaitForSync fail when the initial sync ends with more scan or transition problems,
+// a negative value only warns about them
+func (r *RemoteDevelopment) WithMaxAllowedProblems(maxAllowedProblems int) *RemoteDevelopment {
+	r.maxAllowedProblems = maxAllowedProblems
+	return r
+}
+
+// WithStepLabels overrides the labels of the setup steps, e.g. to localize them, missing steps keep the default
+func (r *RemoteDevelopment) WithStepLabels(stepLabels map[Step]string) *RemoteDevelopment {
+	r.stepLabels = stepLabels
+	return r
+}
+
+// WithMetadataDir names a directory the caller keeps its state in, relative to the sync root unless absolute,
+// ignored by the sync when it resides under the sync root. Unset by default, a project's own .bunnyshell
+// directory is synced. The workspace, config file, sync log and temp dir are ignored the same way regardless.
+func (r *RemoteDevelopment) WithMetadataDir(metadataDir string) *RemoteDevelopment {
+	r.metadataDir = metadataDir
+
+	return r
+}
+
+// WithSyncWorkingDirByDefault syncs the working directory, with a notice, when no local sync path is set.
+// Otherwise an unset local sync path fails with ErrLocalSyncPathNotSet.
+func (r *RemoteDevelopment) WithSyncWorkingDirByDefault(syncWorkingDirByDefault bool) *RemoteDevelopment {
+	r.syncWorkingDirByDefault = syncWorkingDirByDefault
+
+	return r
+}
+
+// WithConfigDriftPolicy decides what happens to a reused or watched session whose config no longer matches
+// the current settings, see DetectConfigDrift. Watched sessions are checked every minute.
+func (r *RemoteDevelopment) WithConfigDriftPolicy(configDriftPolicy ConfigDriftPolicy) *RemoteDevelopment {
+	r.configDriftPolicy = configDriftPolicy
+
+	return r
+}
+
+// WithStatusFile writes the session status as JSON on every poll of WaitForSync and WatchAndRestart,
+// see StatusFile and StatusFilePath
+func (r *RemoteDevelopment) WithStatusFile(statusFileEnabled bool) *RemoteDevelopment {
+	r.statusFileEnabled = statusFileEnabled
+
+	return r
+}
+
+// WithDownloadBufferSize sets the buffer the mutagen archive is copied to disk with, 256KB when not positive
+func (r *RemoteDevelopment) WithDownloadBufferSize(downloadBufferSize int) *RemoteDevelopment {
+	r.downloadBufferSize = downloadBufferSize
+
+	return r
+}
+
+// WithOnDownloaded registers a callback fired once a downloaded mutagen binary is verified and installed,
+// e.g. to record its provenance. The checksum is the sha256 of the release archive, provisioned or not.
+func (r *RemoteDevelopment) WithOnDownloaded(onDownloaded func(path, version, checksum string)) *RemoteDevelopment {
+	r.onDownloaded = onDownloaded
+
+	return r
+}
+
+// WithSyncDebounce coalesces noisy file activity into at most one sync cycle per interval, zero disables it.
+// Mutagen's own watch already coalesces the events of a short burst into one cycle, but keeps cycling
+// while a tool writes continuously. With a debounce the watch is disabled: changes reach the other endpoint
+// up to the interval late and, without watch events, every cycle rescans the whole tree.
+func (r *RemoteDevelopment) WithSyncDebounce(syncDebounce time.Duration) *RemoteDevelopment {
+	r.syncDebounce = syncDebounce
+
+	return r
+}
+
+// WithDebugDaemon is a diagnostic escape hatch, not for normal operation: the mutagen daemon runs attached
+// to our process with debug logging, see WithLogFile, and is stopped by Close. Any running daemon is stopped first.
+func (r *RemoteDevelopment) WithDebugDaemon(debugDaemonEnabled bool) *RemoteDevelopment {
+	r.debugDaemonEnabled = debugDaemonEnabled
+
+	return r
+}
+
+// WithFlushOnTerminate flushes the session before it is terminated by Close or Down, so the last saved changes
+// are delivered. The flush is abandoned after timeout, defaultFlushOnTerminateTimeout when not positive.
+func (r *RemoteDevelopment) WithFlushOnTerminate(enabled bool, timeout time.Duration) *RemoteDevelopment {
+	if !enabled {
+		r.flushOnTerminateTimeout = 0
+		return r
+	}
+
+	if timeout <= 0 {
+		timeout = defaultFlushOnTerminateTimeout
+	}
+
+	r.flushOnTerminateTimeout = timeout
+	return r
+}
+
+// WithLogFile mirrors the mutagen commands, their output and the polled session status to logFile,
+// relative to the workspace unless absolute. The file is rotated past 10MB.
+func (r *RemoteDevelopment) WithLogFile(logFile string) *RemoteDevelopment {
+	logPath, err := getSyncLogPath(logFile)
+	if err != nil {
+		fmt.Printf("WARNING: sync log disabled: %s\n", err)
+		return r
+	}
+
+	r.syncLog = &syncLog{path: logPath}
+	return r
+}
+
+// WithLatencyLog logs the remote latency before the session starts, see MeasureLatency
+func (r *RemoteDevelopment) WithLatencyLog(logLatency bool) *RemoteDevelopment {
+	r.logLatency = logLatency
+	return r
+}
+
+// WithPurgeRemoteOnAbort makes AbortSync delete the remote copies of the local files.
+// Dangerous: remote files sharing a path with a local file are deleted even if they predate the sync.
+func (r *RemoteDevelopment) WithPurgeRemoteOnAbort(purgeRemoteOnAbort bool) *RemoteDevelopment {
+	r.purgeRemoteOnAbort = purgeRemoteOnAbort
+	return r
+}
+
+// WithIgnoreGroup toggles a named group of ignores, see mutagenConfig.IgnoreGroup.
+// The vcs and custom groups are enabled by default.
+func (r *RemoteDevelopment) WithIgnoreGroup(group mutagenConfig.IgnoreGroup, enabled bool) *RemoteDevelopment {
+	r.ignoreGroups[group] = enabled
+	return r
+}
+
+// WithCustomIgnores adds paths to the custom ignore group, in the mutagen ignore syntax
+func (r *RemoteDevelopment) WithCustomIgnores(paths ...string) *RemoteDevelopment {
+	r.customIgnores = append(r.customIgnores, paths...)
+	return r
+}
+
+// WithIgnoreLargeBinaries excludes common large binary file types from sync, see mutagenConfig.LargeBinaryExtensions
+func (r *RemoteDevelopment) WithIgnoreLargeBinaries(extraExtensions ...string) *RemoteDevelopment {
+	r.ignoreGroups[mutagenConfig.IgnoreGroupLargeBinaries] = true
+	r.largeBinaryExtensions = append(r.largeBinaryExtensions, extraExtensions...)
+	return r
+}
+
+// WithInitialScanIgnores excludes paths, e.g. a big assets dir, from the initial sync only, to get the app running fast.
+// Once WaitForSync sees the initial sync complete, the session is recreated without them, see finishInitialScan.
+func (r *RemoteDevelopment) WithInitialScanIgnores(paths ...string) *RemoteDevelopment {
+	r.initialScanIgnores = paths
+	r.initialScanPhase = len(paths) > 0
+	return r
+}
+
+// WithIgnoreOlderThan excludes the files not modified within olderThan, e.g. stale artifacts, and the directories
+// containing only such files. The list is computed when the config is generated, on every session start:
+// a stale file edited later, or a file created in an excluded directory, stays unsynced until the session restarts.
+func (r *RemoteDevelopment) WithIgnoreOlderThan(olderThan time.Duration) *RemoteDevelopment {
+	r.ignoreOlderThan = olderThan
+	return r
+}
+
+// WithGitignore merges the patterns of the .gitignore files of the local sync path into the mutagen ignores,
+// see getGitignorePaths for the unsupported constructs
+func (r *RemoteDevelopment) WithGitignore(useGitignore bool) *RemoteDevelopment {
+	r.useGitignore = useGitignore
+	return r
+}
+
 func (r *RemoteDevelopment) WithSSH(sshPrivateKeyPath, sshPublicKeyPath string) *RemoteDevelopment {
 	r.sshPrivateKeyPath = sshPrivateKeyPath
 	r.sshPublicKeyPath = sshPublicKeyPath
 	return r
 }
 
+func (r *RemoteDevelopment) WithSSHOptions(sshOptions *SSHOptions) *RemoteDevelopment {
+	r.sshOptions = sshOptions
+	return r
+}
+
+// WithRemoteShell runs the remote commands, e.g. creating the remote sync path, through shell instead of
+// the login shell of the remote user. Mutagen's agent always uses the login shell, which must be POSIX compatible.
+func (r *RemoteDevelopment) WithRemoteShell(shell string) *RemoteDevelopment {
+	r.remoteShell = shell
+	return r
+}
+
+// WithDockerEndpoint syncs with a docker container instead of the pod's SSH endpoint.
+// Remote commands, e.g. creating the remote sync path, run through "docker exec" as well.
+func (r *RemoteDevelopment) WithDockerEndpoint(containerName, user string) *RemoteDevelopment {
+	r.dockerEndpoint = &DockerEndpoint{
+		Container: containerName,
+		User:      user,
+	}
+	return r
+}
+
 func (r *RemoteDevelopment) WithKubernetesClient(kubeConfigPath string) *RemoteDevelopment {
 	kubernetesClient, err := k8s.NewKubernetesClient(kubeConfigPath)
 	if err != nil {
@@ -0,0 +1,119 @@
+package remote
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	mutagenConfig "bunnyshell.com/dev/pkg/mutagen/config"
+)
+
+const (
+	mutagenLabelReplica = "remote-dev.bunnyshell.com/replica"
+)
+
+// ReplicaEndpoint is one pod receiving the same local changes
+type ReplicaEndpoint struct {
+	// Name identifies the replica, usually the pod name
+	Name string
+	// Host is the SSH host mutagen connects to, e.g. an ssh config alias
+	Host string
+}
+
+// SyncReplicas creates one mutagen session per endpoint, all sharing the local path and config.
+// Sessions created before a failure are terminated.
+func (r *RemoteDevelopment) SyncReplicas(endpoints []ReplicaEndpoint) error {
+	if r.syncMode == mutagenConfig.None {
+		return nil
+	}
+
+	for _, endpoint := range endpoints {
+		sessionName, err := r.getReplicaSessionName(endpoint.Name)
+		if err != nil {
+			r.TerminateReplicas()
+			return err
+		}
+
+		labels := map[string]string{
+			mutagenLabelReplica: getReplicaKey(endpoint.Name),
+		}
+		if err := r.execMutagenSyncCreate(sessionName, r.localSyncPath, fmt.Sprintf("%s:%s", endpoint.Host, r.remoteSyncPath), labels); err != nil {
+			r.TerminateReplicas()
+			return fmt.Errorf("cannot sync replica %s: %w", endpoint.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// ReplicaSessions returns the sessions created by SyncReplicas
+func (r *RemoteDevelopment) ReplicaSessions() ([]MutagenSession, error) {
+	sessionKey, err := r.getMutagenSessionKey()
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := r.listMutagenSessions()
+	if err != nil {
+		return nil, err
+	}
+
+	replicaSessions := []MutagenSession{}
+	for _, session := range sessions {
+		if session.Labels[mutagenLabelSessionKey] != sessionKey {
+			continue
+		}
+
+		if _, ok := session.Labels[mutagenLabelReplica]; ok {
+			replicaSessions = append(replicaSessions, session)
+		}
+	}
+
+	return replicaSessions, nil
+}
+
+func (r *RemoteDevelopment) TerminateReplicas() error {
+	mutagenBinPath, err := r.getMutagenBinPath()
+	if err != nil {
+		return err
+	}
+
+	sessions, err := r.ReplicaSessions()
+	if err != nil {
+		return err
+	}
+
+	errs := []string{}
+	for _, session := range sessions {
+		output, err := r.newMutagenCommand(mutagenBinPath, "sync", "terminate", session.Identifier).CombinedOutput()
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", session.Name, strings.TrimSpace(string(output))))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("cannot terminate replica sessions: %s", strings.Join(errs, "; "))
+	}
+
+	return nil
+}
+
+func (r *RemoteDevelopment) getReplicaSessionName(replicaName string) (string, error) {
+	sessionName, err := r.getMutagenSessionName()
+	if err != nil {
+		return "", err
+	}
+
+	replicaSessionName := fmt.Sprintf("%s-%s", sessionName, getReplicaKey(replicaName))
+	if err := validateMutagenSessionName(replicaSessionName); err != nil {
+		return "", err
+	}
+
+	return replicaSessionName, nil
+}
+
+func getReplicaKey(replicaName string) string {
+	hash := md5.Sum([]byte(replicaName))
+	return hex.EncodeToString(hash[:])[:8]
+}
@@ -0,0 +1,32 @@
+package remote
+
+import (
+	mutagenConfig "bunnyshell.com/dev/pkg/mutagen/config"
+)
+
+// RestartSession recreates the session from the current settings, content is kept on both endpoints
+// but the scan state and sync history are lost, see finishInitialScan for the consequences
+func (r *RemoteDevelopment) RestartSession() error {
+	if r.syncMode == mutagenConfig.None {
+		return nil
+	}
+
+	if _, err := r.WriteConfig(); err != nil {
+		return err
+	}
+
+	if err := r.terminateMutagenSession(); err != nil {
+		return err
+	}
+
+	return r.createMutagenSession()
+}
+
+// UpdateIgnores replaces the custom ignores of the running session.
+// Mutagen bakes the configuration in a session at creation and has no way to update it in place,
+// so the session is restarted, see RestartSession.
+func (r *RemoteDevelopment) UpdateIgnores(paths []string) error {
+	r.customIgnores = paths
+
+	return r.RestartSession()
+}
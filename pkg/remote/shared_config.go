@@ -0,0 +1,104 @@
+package remote
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	mutagenConfig "bunnyshell.com/dev/pkg/mutagen/config"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SharedConfig holds the sync settings worth standardizing across a team. Machine specific and sensitive settings,
+// e.g. the local path, SSH keys, download headers and mutagen env, are left out.
+// The .rdignore file lives in the project, so it is shared with it rather than exported.
+type SharedConfig struct {
+	SyncMode       mutagenConfig.Mode     `yaml:"syncMode,omitempty"`
+	ScanMode       mutagenConfig.ScanMode `yaml:"scanMode,omitempty"`
+	RemoteSyncPath string                 `yaml:"remoteSyncPath,omitempty"`
+	Reverse        bool                   `yaml:"reverse,omitempty"`
+
+	CreateRemoteSyncPath bool `yaml:"createRemoteSyncPath,omitempty"`
+	VerifyRemoteSyncPath bool `yaml:"verifyRemoteSyncPath,omitempty"`
+
+	Gitignore             bool                               `yaml:"gitignore,omitempty"`
+	IgnoreGroups          map[mutagenConfig.IgnoreGroup]bool `yaml:"ignoreGroups,omitempty"`
+	CustomIgnores         []string                           `yaml:"customIgnores,omitempty"`
+	LargeBinaryExtensions []string                           `yaml:"largeBinaryExtensions,omitempty"`
+	InitialScanIgnores    []string                           `yaml:"initialScanIgnores,omitempty"`
+
+	SessionNamePrefix  string `yaml:"sessionNamePrefix,omitempty"`
+	MaxAllowedProblems *int   `yaml:"maxAllowedProblems,omitempty"`
+}
+
+// ExportConfig serializes the shareable settings, see SharedConfig
+func (r *RemoteDevelopment) ExportConfig() ([]byte, error) {
+	config := SharedConfig{
+		SyncMode:       r.syncMode,
+		ScanMode:       r.scanMode,
+		RemoteSyncPath: r.remoteSyncPath,
+		Reverse:        r.reverse,
+
+		CreateRemoteSyncPath: r.createRemoteSyncPath,
+		VerifyRemoteSyncPath: r.verifyRemoteSyncPath,
+
+		Gitignore:             r.useGitignore,
+		IgnoreGroups:          r.ignoreGroups,
+		CustomIgnores:         r.customIgnores,
+		LargeBinaryExtensions: r.largeBinaryExtensions,
+		InitialScanIgnores:    r.initialScanIgnores,
+
+		SessionNamePrefix: r.sessionNamePrefix,
+	}
+
+	if r.maxAllowedProblems >= 0 {
+		config.MaxAllowedProblems = &r.maxAllowedProblems
+	}
+
+	return yaml.Marshal(config)
+}
+
+// ImportConfig applies settings exported by ExportConfig, unknown keys are rejected to catch typos.
+// Settings missing from data are reset to their defaults, Validate reports inconsistent values.
+func (r *RemoteDevelopment) ImportConfig(data []byte) error {
+	config := SharedConfig{}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&config); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("cannot parse shared config: %w", err)
+	}
+
+	if config.SyncMode == "" {
+		config.SyncMode = mutagenConfig.TwoWayResolved
+	}
+	if config.SessionNamePrefix == "" {
+		config.SessionNamePrefix = defaultSessionNamePrefix
+	}
+	maxAllowedProblems := unlimitedProblems
+	if config.MaxAllowedProblems != nil {
+		maxAllowedProblems = *config.MaxAllowedProblems
+	}
+
+	r.WithSyncMode(config.SyncMode).
+		WithScanMode(config.ScanMode).
+		WithRemoteSyncPath(config.RemoteSyncPath).
+		WithReverse(config.Reverse).
+		WithVerifyRemoteSyncPath(config.VerifyRemoteSyncPath).
+		WithGitignore(config.Gitignore).
+		WithInitialScanIgnores(config.InitialScanIgnores...).
+		WithSessionNamePrefix(config.SessionNamePrefix).
+		WithMaxAllowedProblems(maxAllowedProblems)
+
+	r.createRemoteSyncPath = config.CreateRemoteSyncPath
+	r.ignoreGroups = newDefaultIgnoreGroups()
+	for group, enabled := range config.IgnoreGroups {
+		r.ignoreGroups[group] = enabled
+	}
+	r.customIgnores = config.CustomIgnores
+	r.largeBinaryExtensions = config.LargeBinaryExtensions
+
+	return nil
+}
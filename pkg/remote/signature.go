@@ -0,0 +1,63 @@
+package remote
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+)
+
+const (
+	// mutagenSignatureSuffix names the detached, armored signature published next to a release archive
+	mutagenSignatureSuffix = ".asc"
+)
+
+var (
+	ErrSignatureVerification = fmt.Errorf("mutagen release signature verification failed")
+)
+
+// parseSigningKey reads the armored public key the release signatures are verified against
+func parseSigningKey(armoredKey string) (openpgp.EntityList, error) {
+	keyRing, err := openpgp.ReadArmoredKeyRing(strings.NewReader(armoredKey))
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse signing key: %w", err)
+	}
+
+	return keyRing, nil
+}
+
+// verifyMutagenArchiveSignature downloads the detached signature of the archive and checks it against the
+// signing key, before the archive is extracted. Only a failed download of the signature is worth retrying,
+// other failures wrap ErrSignatureVerification.
+func verifyMutagenArchiveSignature(downloadUrl, mutagenArchivePath string, options mutagenDownloadOptions) error {
+	keyRing, err := parseSigningKey(options.signingKey)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrSignatureVerification, err)
+	}
+
+	signaturePath := mutagenArchivePath + mutagenSignatureSuffix
+	defer os.Remove(signaturePath)
+
+	if err := downloadMutagenArchive(downloadUrl+mutagenSignatureSuffix, signaturePath, options); err != nil {
+		return fmt.Errorf("cannot download the release signature: %w", err)
+	}
+
+	archive, err := os.Open(mutagenArchivePath)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+
+	signature, err := os.Open(signaturePath)
+	if err != nil {
+		return err
+	}
+	defer signature.Close()
+
+	if _, err := openpgp.CheckArmoredDetachedSignature(keyRing, archive, signature, nil); err != nil {
+		return fmt.Errorf("%w for %s: %w", ErrSignatureVerification, mutagenArchivePath, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package remote
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+)
+
+func newArmoredTestKey(t *testing.T) (*openpgp.Entity, string) {
+	t.Helper()
+
+	entity, err := openpgp.NewEntity("release", "", "release@example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	armored := &bytes.Buffer{}
+	writer, err := armor.Encode(armored, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := entity.Serialize(writer); err != nil {
+		t.Fatal(err)
+	}
+	writer.Close()
+
+	return entity, armored.String()
+}
+
+func TestSignatureFailureIsNotRetried(t *testing.T) {
+	_, signingKey := newArmoredTestKey(t)
+	otherEntity, _ := newArmoredTestKey(t)
+
+	archive := []byte("not the released archive")
+	signature := &bytes.Buffer{}
+	if err := openpgp.ArmoredDetachSign(signature, otherEntity, bytes.NewReader(archive), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	archiveRequests := int32(0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, mutagenSignatureSuffix) {
+			w.Write(signature.Bytes())
+			return
+		}
+
+		atomic.AddInt32(&archiveRequests, 1)
+		w.Write(archive)
+	}))
+	defer server.Close()
+
+	options := mutagenDownloadOptions{
+		baseUrl:         server.URL,
+		attempts:        3,
+		verifySignature: true,
+		signingKey:      signingKey,
+	}
+	mutagenBinPath := filepath.Join(t.TempDir(), mutagenBinFilename)
+
+	err := downloadMutagenBin("v0.15.3", "linux", "amd64", mutagenBinPath, 0700, options)
+	if !errors.Is(err, ErrSignatureVerification) {
+		t.Fatalf("got %v, want ErrSignatureVerification", err)
+	}
+
+	downloadError := &DownloadError{}
+	if errors.As(err, &downloadError) {
+		t.Errorf("got a DownloadError, the signature failure must be returned directly: %v", err)
+	}
+
+	if requests := atomic.LoadInt32(&archiveRequests); requests != 1 {
+		t.Errorf("the archive was downloaded %d times, want 1", requests)
+	}
+}
@@ -1,13 +1,48 @@
 package remote
 
+// StartSpinner may be nested or called concurrently, the spinner keeps running until every caller stopped it.
+// The latest suffix is displayed.
 func (r *RemoteDevelopment) StartSpinner(suffix string) {
+	r.spinnerMutex.Lock()
+	defer r.spinnerMutex.Unlock()
+
 	if suffix != "" {
+		r.spinner.Lock()
 		r.spinner.Suffix = suffix
+		r.spinner.Unlock()
 	}
 
-	r.spinner.Start()
+	r.spinnerUsers++
+	if r.spinnerUsers == 1 {
+		r.spinner.Start()
+	}
 }
 
 func (r *RemoteDevelopment) StopSpinner() {
-	r.spinner.Stop()
+	r.spinnerMutex.Lock()
+	defer r.spinnerMutex.Unlock()
+
+	if r.spinnerUsers == 0 {
+		return
+	}
+
+	r.spinnerUsers--
+	if r.spinnerUsers == 0 {
+		r.spinner.Stop()
+	}
+}
+
+// pauseSpinner hides the spinner while print writes to the terminal, whatever the number of callers running it,
+// and shows it again only if it was running. Stopping and restarting it instead would leak it outside a step
+// and keep it running inside nested steps.
+func (r *RemoteDevelopment) pauseSpinner(print func()) {
+	r.spinnerMutex.Lock()
+	defer r.spinnerMutex.Unlock()
+
+	if r.spinnerUsers > 0 {
+		r.spinner.Stop()
+		defer r.spinner.Start()
+	}
+
+	print()
 }
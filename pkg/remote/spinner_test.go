@@ -0,0 +1,91 @@
+package remote
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestSpinnerConcurrentStartStop(t *testing.T) {
+	r := NewRemoteDevelopment()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			r.StartSpinner(" step")
+			r.StartSpinner("")
+			r.StopSpinner()
+			r.StopSpinner()
+		}()
+	}
+	wg.Wait()
+
+	if r.spinnerUsers != 0 {
+		t.Errorf("got %d spinner users after every caller stopped, want 0", r.spinnerUsers)
+	}
+}
+
+func TestSpinnerStopWithoutStart(t *testing.T) {
+	r := NewRemoteDevelopment()
+
+	r.StopSpinner()
+	r.StartSpinner("")
+	r.StopSpinner()
+
+	if r.spinnerUsers != 0 {
+		t.Errorf("got %d spinner users, want 0", r.spinnerUsers)
+	}
+}
+
+func TestPauseSpinnerKeepsUsers(t *testing.T) {
+	r := NewRemoteDevelopment()
+
+	printed := false
+	r.pauseSpinner(func() { printed = true })
+	if !printed {
+		t.Error("expected the print function to run")
+	}
+	if r.spinnerUsers != 0 {
+		t.Errorf("pausing outside of a step left %d spinner users, want 0", r.spinnerUsers)
+	}
+
+	r.StartSpinner(" outer")
+	r.StartSpinner(" inner")
+	r.pauseSpinner(func() {})
+	if r.spinnerUsers != 2 {
+		t.Errorf("pausing inside nested steps left %d spinner users, want 2", r.spinnerUsers)
+	}
+	r.StopSpinner()
+	r.StopSpinner()
+
+	if r.spinnerUsers != 0 {
+		t.Errorf("got %d spinner users, want 0", r.spinnerUsers)
+	}
+}
+
+func TestPauseSpinnerConcurrent(t *testing.T) {
+	r := NewRemoteDevelopment()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+
+			r.StartSpinner("")
+			r.StopSpinner()
+		}()
+		go func() {
+			defer wg.Done()
+
+			r.pauseSpinner(func() {})
+		}()
+	}
+	wg.Wait()
+
+	if r.spinnerUsers != 0 {
+		t.Errorf("got %d spinner users, want 0", r.spinnerUsers)
+	}
+}
@@ -8,8 +8,12 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strconv"
+	"strings"
 
+	mutagenConfig "bunnyshell.com/dev/pkg/mutagen/config"
 	bunnyshellSSH "bunnyshell.com/dev/pkg/ssh"
 	"bunnyshell.com/dev/pkg/util"
 
@@ -29,11 +33,79 @@ const (
 	paramIdentityFile           = "IdentityFile"
 	paramIdentitiesOnly         = "IdentitiesOnly"
 	paramPubkeyAcceptedKeyTypes = "PubkeyAcceptedKeyTypes"
+	paramProxyJump              = "ProxyJump"
+	paramProxyCommand           = "ProxyCommand"
 
 	SyncthingRemoteInterface = "127.0.0.1"
 	SyncthingRemotePort      = 22000
 )
 
+// SSHOptions customizes the ssh config entry used by mutagen's SSH transport.
+// Values are written verbatim, except UserKnownHostsFile which is quoted since paths may contain spaces.
+type SSHOptions struct {
+	// ProxyJump is a comma separated list of [user@]host[:port] jump hosts. It is not supported yet:
+	// the pod is reached through a local kubectl port-forward, a jump host would connect to its own loopback.
+	ProxyJump string
+	// StrictHostKeyChecking accepts the ssh_config values: yes, no, accept-new, ask, off
+	StrictHostKeyChecking string
+	UserKnownHostsFile    string
+	// HostKeyFingerprint pins the host key, e.g. "SHA256:...", as printed by ssh-keygen -lf.
+	// It takes over StrictHostKeyChecking and UserKnownHostsFile.
+	HostKeyFingerprint string
+
+	// Extra holds any other ssh_config parameter
+	Extra map[string]string
+}
+
+func (o *SSHOptions) Validate() error {
+	if o.ProxyJump != "" {
+		return fmt.Errorf("%w: ProxyJump \"%s\"", ErrSSHOptionUnsupported, o.ProxyJump)
+	}
+
+	switch o.StrictHostKeyChecking {
+	case "", "yes", "no", "accept-new", "ask", "off":
+	default:
+		return fmt.Errorf("invalid StrictHostKeyChecking \"%s\"", o.StrictHostKeyChecking)
+	}
+
+	if o.HostKeyFingerprint != "" && !strings.HasPrefix(o.HostKeyFingerprint, hostKeyFingerprintPrefix) {
+		return fmt.Errorf("invalid HostKeyFingerprint \"%s\": expected a %s fingerprint", o.HostKeyFingerprint, hostKeyFingerprintPrefix)
+	}
+
+	if o.HostKeyFingerprint != "" && (o.StrictHostKeyChecking != "" || o.UserKnownHostsFile != "") {
+		return fmt.Errorf("HostKeyFingerprint cannot be combined with StrictHostKeyChecking or UserKnownHostsFile")
+	}
+
+	if strings.Contains(o.UserKnownHostsFile, "\"") {
+		return fmt.Errorf("invalid UserKnownHostsFile \"%s\": quotes are not allowed", o.UserKnownHostsFile)
+	}
+
+	for key, value := range o.Extra {
+		if key == "" || strings.ContainsAny(key, " \t=") {
+			return fmt.Errorf("invalid ssh option name \"%s\"", key)
+		}
+		// ssh_config keywords are case-insensitive
+		if slices.ContainsFunc(transportSSHParams, func(param string) bool { return strings.EqualFold(param, key) }) {
+			return fmt.Errorf("%w: %s is set by the port-forward transport", ErrSSHOptionUnsupported, key)
+		}
+		if strings.ContainsAny(value, "\r\n") {
+			return fmt.Errorf("invalid value for ssh option %s: line breaks are not allowed", key)
+		}
+	}
+
+	return nil
+}
+
+var (
+	ErrRemoteSyncPathNotWritable = fmt.Errorf("remote sync path not writable by container user")
+	ErrSSHOptionUnsupported      = fmt.Errorf("ssh option not supported by the port-forward transport")
+
+	// transportSSHParams route the connection through the local port-forward, the Extra options cannot override them
+	transportSSHParams = []string{paramHostName, paramPort, paramProxyJump, paramProxyCommand}
+
+	networkFilesystemTypes = []string{"nfs", "nfs4", "cifs", "smb2", "smbfs", "fuse.sshfs", "9p", "ceph", "glusterfs", "lustre"}
+)
+
 func (r *RemoteDevelopment) ensureSSHKeys() error {
 	workspace, err := util.GetRemoteDevWorkspaceDir()
 	if err != nil {
@@ -49,7 +121,7 @@ func (r *RemoteDevelopment) ensureSSHKeys() error {
 		return nil
 	}
 
-	spinner := util.MakeSpinner(" Generate SSH RSA key...")
+	spinner := util.MakeSpinner(" " + r.StepLabel(StepGenerateSSHKey))
 	spinner.Start()
 	defer spinner.Stop()
 
@@ -106,11 +178,24 @@ func (r *RemoteDevelopment) ensureSSHConfigEntry() error {
 		return err
 	}
 	bunnyshellSSH.RemoveHost(config, hostname)
+	sshOptions := r.sshOptions
+	if sshOptions == nil {
+		sshOptions = &SSHOptions{}
+	}
+	if err := sshOptions.Validate(); err != nil {
+		return err
+	}
+	sshOptions, err = r.ensurePinnedHostKey(sshOptions)
+	if err != nil {
+		return err
+	}
+
 	host, err := newSSHConfigHost(
 		hostname,
 		r.sshPortForwardOptions.Interface,
 		strconv.Itoa(r.sshPortForwardOptions.LocalPort),
 		r.sshPrivateKeyPath,
+		sshOptions,
 	)
 	if err != nil {
 		return err
@@ -134,22 +219,41 @@ func (r *RemoteDevelopment) getSSHHostname() (string, error) {
 	return fmt.Sprintf("%s.%s.bunnyshell", resource.GetName(), resource.GetNamespace()), nil
 }
 
-func newSSHConfigHost(hostname, iface, port, identityFile string) (*ssh_config.Host, error) {
+func newSSHConfigHost(hostname, iface, port, identityFile string, options *SSHOptions) (*ssh_config.Host, error) {
 	pattern, err := ssh_config.NewPattern(hostname)
 	if err != nil {
 		return nil, err
 	}
+
+	strictHostKeyChecking := "no"
+	if options.StrictHostKeyChecking != "" {
+		strictHostKeyChecking = options.StrictHostKeyChecking
+	}
+	userKnownHostsFile := "/dev/null"
+	if options.UserKnownHostsFile != "" {
+		userKnownHostsFile = fmt.Sprintf("\"%s\"", options.UserKnownHostsFile)
+	}
+
 	patterns := []*ssh_config.Pattern{pattern}
 	nodes := []ssh_config.Node{
 		bunnyshellSSH.NewKV(paramForwardAgent, "yes"),
 		bunnyshellSSH.NewKV(paramHostName, iface),
 		bunnyshellSSH.NewKV(paramPort, port),
-		bunnyshellSSH.NewKV(paramStrictHostKeyChecking, "no"),
-		bunnyshellSSH.NewKV(paramUserKnownHostsFile, "/dev/null"),
+		bunnyshellSSH.NewKV(paramStrictHostKeyChecking, strictHostKeyChecking),
+		bunnyshellSSH.NewKV(paramUserKnownHostsFile, userKnownHostsFile),
 		bunnyshellSSH.NewKV(paramIdentityFile, identityFile),
 		bunnyshellSSH.NewKV(paramIdentitiesOnly, "yes"),
 		bunnyshellSSH.NewKV(paramPubkeyAcceptedKeyTypes, "+ssh-rsa"),
 	}
+	extraKeys := make([]string, 0, len(options.Extra))
+	for key := range options.Extra {
+		extraKeys = append(extraKeys, key)
+	}
+	sort.Strings(extraKeys)
+	for _, key := range extraKeys {
+		nodes = append(nodes, bunnyshellSSH.NewKV(key, options.Extra[key]))
+	}
+
 	host := &ssh_config.Host{
 		Patterns: patterns,
 		Nodes:    nodes,
@@ -184,3 +288,130 @@ func (r *RemoteDevelopment) StartSSHTerminal() error {
 
 	return nil
 }
+
+func (r *RemoteDevelopment) runRemoteCommand(command string) ([]byte, error) {
+	if r.dockerEndpoint != nil {
+		return r.dockerEndpoint.runCommand(r.getRemoteShell(), command)
+	}
+
+	auth, err := bunnyshellSSH.PrivateKeyFile(r.sshPrivateKeyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	server := bunnyshellSSH.NewEndpoint(r.sshPortForwardOptions.Interface, r.sshPortForwardOptions.LocalPort)
+
+	return bunnyshellSSH.RunCommand(server, auth, r.wrapRemoteShell(command))
+}
+
+// runRemoteCommandOutput is runRemoteCommand leaving stderr out, for output compared across runs
+func (r *RemoteDevelopment) runRemoteCommandOutput(command string) ([]byte, error) {
+	if r.dockerEndpoint != nil {
+		return r.dockerEndpoint.runCommandOutput(r.getRemoteShell(), command)
+	}
+
+	auth, err := bunnyshellSSH.PrivateKeyFile(r.sshPrivateKeyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	server := bunnyshellSSH.NewEndpoint(r.sshPortForwardOptions.Interface, r.sshPortForwardOptions.LocalPort)
+
+	return bunnyshellSSH.RunCommandOutput(server, auth, r.wrapRemoteShell(command))
+}
+
+func (r *RemoteDevelopment) ensureRemoteSyncPath() error {
+	if !r.createRemoteSyncPath || r.syncMode == mutagenConfig.None {
+		return nil
+	}
+
+	r.startStep(StepCreateRemoteSyncPath)
+	defer r.StopSpinner()
+
+	remoteSyncPath := bunnyshellSSH.QuoteArg(r.remoteSyncPath)
+	commands := []string{fmt.Sprintf("mkdir -p %s", remoteSyncPath)}
+	if r.remoteSyncPathMode != 0 {
+		commands = append(commands, fmt.Sprintf("chmod %o %s", r.remoteSyncPathMode.Perm(), remoteSyncPath))
+	}
+	if r.remoteSyncPathOwner != "" {
+		commands = append(commands, fmt.Sprintf("chown %s %s", bunnyshellSSH.QuoteArg(r.remoteSyncPathOwner), remoteSyncPath))
+	}
+
+	output, err := r.runRemoteCommand(strings.Join(commands, " && "))
+	if err != nil {
+		return fmt.Errorf("cannot create remote sync path %s: %w: %s", r.remoteSyncPath, err, strings.TrimSpace(string(output)))
+	}
+
+	return nil
+}
+
+func (r *RemoteDevelopment) verifyRemoteSyncPathWritable() error {
+	if !r.verifyRemoteSyncPath || r.syncMode == mutagenConfig.None {
+		return nil
+	}
+
+	r.startStep(StepVerifyRemoteSyncPath)
+	defer r.StopSpinner()
+
+	probePath := bunnyshellSSH.QuoteArg(fmt.Sprintf("%s/.bunnyshell-write-probe-%d", strings.TrimSuffix(r.remoteSyncPath, "/"), r.startedAt))
+	command := fmt.Sprintf(
+		"if touch %[1]s 2>/dev/null && rm -f %[1]s; then exit 0; else echo \"$(id -u):$(id -g)\"; exit 1; fi",
+		probePath,
+	)
+
+	output, err := r.runRemoteCommand(command)
+	if err != nil && len(output) == 0 {
+		return fmt.Errorf("cannot verify remote sync path %s: %w", r.remoteSyncPath, err)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %s (uid:gid %s)", ErrRemoteSyncPathNotWritable, r.remoteSyncPath, strings.TrimSpace(string(output)))
+	}
+
+	return nil
+}
+
+// inspectRemoteSyncPath warns about remote paths likely to make mutagen scan far more than intended,
+// it never fails the session since such a layout may be deliberate
+func (r *RemoteDevelopment) inspectRemoteSyncPath() error {
+	if !r.inspectRemoteSyncPathLayout || r.syncMode == mutagenConfig.None {
+		return nil
+	}
+
+	// prints the resolved path and its filesystem type, stat -f being missing from some minimal images
+	command := fmt.Sprintf(
+		"p=%s; [ -e \"$p\" ] || [ -L \"$p\" ] || exit 0; r=$(readlink -f \"$p\") || { echo loop; exit 0; }; echo \"$r\"; stat -f -c %%T \"$r\" 2>/dev/null || true",
+		bunnyshellSSH.QuoteArg(r.remoteSyncPath),
+	)
+
+	r.startStep(StepInspectRemoteSyncPath)
+	output, err := r.runRemoteCommand(command)
+	r.StopSpinner()
+	if err != nil {
+		return fmt.Errorf("cannot inspect remote sync path %s: %w: %s", r.remoteSyncPath, err, strings.TrimSpace(string(output)))
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	resolvedPath := strings.TrimSpace(lines[0])
+	filesystemType := ""
+	if len(lines) > 1 {
+		filesystemType = strings.TrimSpace(lines[1])
+	}
+
+	switch {
+	case resolvedPath == "":
+		return nil
+	case resolvedPath == "loop":
+		fmt.Printf("WARNING: remote sync path %s cannot be resolved, it may be a symlink loop\n", r.remoteSyncPath)
+		return nil
+	case resolvedPath == "/":
+		fmt.Printf("WARNING: remote sync path %s resolves to /, mutagen would scan the whole container\n", r.remoteSyncPath)
+	case resolvedPath != strings.TrimSuffix(r.remoteSyncPath, "/"):
+		fmt.Printf("INFO: remote sync path %s is a symlink to %s\n", r.remoteSyncPath, resolvedPath)
+	}
+
+	if slices.Contains(networkFilesystemTypes, filesystemType) {
+		fmt.Printf("WARNING: remote sync path %s is on a %s network mount, scans may be slow and traverse a large volume\n", resolvedPath, filesystemType)
+	}
+
+	return nil
+}
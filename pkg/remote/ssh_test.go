@@ -0,0 +1,24 @@
+package remote
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSSHOptionsRejectTransportParams(t *testing.T) {
+	for name, options := range map[string]SSHOptions{
+		"proxy jump":       {ProxyJump: "bastion.example.com"},
+		"extra host name":  {Extra: map[string]string{"hostname": "10.0.0.5"}},
+		"extra port":       {Extra: map[string]string{"Port": "2222"}},
+		"extra proxy jump": {Extra: map[string]string{"ProxyJump": "bastion.example.com"}},
+	} {
+		if err := options.Validate(); !errors.Is(err, ErrSSHOptionUnsupported) {
+			t.Errorf("%s: got %v, want ErrSSHOptionUnsupported", name, err)
+		}
+	}
+
+	options := SSHOptions{Extra: map[string]string{"ServerAliveInterval": "30"}}
+	if err := options.Validate(); err != nil {
+		t.Errorf("got %v for a supported option", err)
+	}
+}
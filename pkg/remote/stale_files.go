@@ -0,0 +1,64 @@
+package remote
+
+import (
+	"os"
+	"path"
+	"time"
+)
+
+const (
+	// maxStaleFileIgnores bounds the ignores added to the mutagen config, the stale files beyond it are synced
+	maxStaleFileIgnores = 1000
+)
+
+// getStaleFileIgnores lists the files of root not modified within olderThan as anchored, escaped ignores.
+// A directory containing no recently modified file is ignored as a whole, keeping the list short for stale trees.
+func getStaleFileIgnores(root string, ignores []string, olderThan time.Duration) ([]string, error) {
+	cutoff := time.Now().Add(-olderThan)
+
+	staleFiles := []string{}
+	freshDirs := map[string]bool{}
+	err := walkLocalFiles(root, ignores, func(relativePath, filePath string) error {
+		stats, err := os.Lstat(filePath)
+		if err != nil {
+			return err
+		}
+
+		if stats.ModTime().Before(cutoff) {
+			staleFiles = append(staleFiles, relativePath)
+			return nil
+		}
+
+		for dir := path.Dir(relativePath); dir != "."; dir = path.Dir(dir) {
+			freshDirs[dir] = true
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	staleIgnores := []string{}
+	ignored := map[string]bool{}
+	for _, staleFile := range staleFiles {
+		stalePath := staleFile
+		for dir := path.Dir(staleFile); dir != "." && !freshDirs[dir]; dir = path.Dir(dir) {
+			stalePath = dir
+		}
+
+		if ignored[stalePath] {
+			continue
+		}
+		ignored[stalePath] = true
+
+		staleIgnores = append(staleIgnores, "/"+mutagenIgnoreEscaper.Replace(stalePath))
+	}
+
+	return staleIgnores, nil
+}
+
+// StaleFiles returns the ignores of the files excluded by WithIgnoreOlderThan, as of the last config generation
+func (r *RemoteDevelopment) StaleFiles() []string {
+	return r.staleFiles
+}
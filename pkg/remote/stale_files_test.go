@@ -0,0 +1,46 @@
+package remote
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestGetStaleFileIgnores(t *testing.T) {
+	root := t.TempDir()
+	stale := time.Now().Add(-48 * time.Hour)
+
+	files := map[string]bool{
+		"build/out.bin":      true,
+		"build/deep/log.txt": true,
+		"src/main.go":        false,
+		"src/old[1].go":      true,
+		"README.md":          true,
+	}
+	for name, isStale := range files {
+		filePath := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filePath), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filePath, []byte(name), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if isStale {
+			if err := os.Chtimes(filePath, stale, stale); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	ignores, err := getStaleFileIgnores(root, nil, 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"/README.md", "/build", `/src/old\[1\].go`}
+	if !slices.Equal(ignores, want) {
+		t.Errorf("got %v, want %v", ignores, want)
+	}
+}
@@ -0,0 +1,246 @@
+package remote
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	mutagenConfig "bunnyshell.com/dev/pkg/mutagen/config"
+)
+
+const (
+	// sessionStateFilename lives in the deployment workspace, see getDeploymentWorkspaceDir
+	sessionStateFilename = "session.json"
+)
+
+var (
+	ErrNoSessionState = fmt.Errorf("no persisted session found")
+)
+
+type SessionState struct {
+	Namespace    string       `json:"namespace"`
+	ResourceType ResourceType `json:"resourceType"`
+	ResourceName string       `json:"resourceName"`
+	SessionScope string       `json:"sessionScope,omitempty"`
+	// DockerContainer replaces the resource for docker endpoints
+	DockerContainer string `json:"dockerContainer,omitempty"`
+
+	SessionName    string             `json:"sessionName"`
+	SyncMode       mutagenConfig.Mode `json:"syncMode"`
+	LocalSyncPath  string             `json:"localSyncPath"`
+	RemoteSyncPath string             `json:"remoteSyncPath"`
+
+	StartedAt      int64         `json:"startedAt"`
+	CreateDuration time.Duration `json:"createDuration"`
+}
+
+func (s *SessionState) identity() string {
+	if s.DockerContainer != "" {
+		return formatDockerSessionStateIdentity(s.DockerContainer, s.SessionScope)
+	}
+
+	return formatSessionStateIdentity(s.Namespace, s.ResourceType, s.ResourceName, s.SessionScope)
+}
+
+// AttachExistingSession restores the sync settings of a session started by a previous invocation
+// for the selected resource, so it can be inspected or terminated
+func (r *RemoteDevelopment) AttachExistingSession() error {
+	identity, err := r.getSessionStateIdentity()
+	if err != nil {
+		return err
+	}
+
+	state, err := findSessionState(identity)
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		return fmt.Errorf("%w for %s", ErrNoSessionState, identity)
+	}
+
+	if err := validateMutagenSessionName(state.SessionName); err != nil {
+		return err
+	}
+
+	r.WithSyncMode(state.SyncMode).
+		WithLocalSyncPath(state.LocalSyncPath).
+		WithRemoteSyncPath(state.RemoteSyncPath)
+
+	// the persisted name wins, a custom namer may not be reproducible
+	r.sessionName = state.SessionName
+
+	return nil
+}
+
+func (r *RemoteDevelopment) saveSessionState() error {
+	if r.syncMode == mutagenConfig.None {
+		return nil
+	}
+
+	sessionName, err := r.getMutagenSessionName()
+	if err != nil {
+		return err
+	}
+
+	previousState, err := r.getSessionState()
+	if err != nil {
+		return err
+	}
+
+	createDuration := r.sessionStartup.Duration
+	if previousState != nil && r.sessionStartup.Reused {
+		createDuration = previousState.CreateDuration
+	}
+
+	deploymentDir, err := r.getDeploymentWorkspaceDir()
+	if err != nil {
+		return err
+	}
+
+	state := SessionState{
+		SessionScope: r.sessionScope,
+
+		SessionName:    sessionName,
+		SyncMode:       r.syncMode,
+		LocalSyncPath:  r.localSyncPath,
+		RemoteSyncPath: r.remoteSyncPath,
+
+		StartedAt:      r.startedAt,
+		CreateDuration: createDuration,
+	}
+
+	if r.dockerEndpoint != nil {
+		state.DockerContainer = r.dockerEndpoint.Container
+	} else {
+		resource, err := r.getResource()
+		if err != nil {
+			return err
+		}
+
+		state.Namespace = resource.GetNamespace()
+		state.ResourceType = r.resourceType
+		state.ResourceName = resource.GetName()
+	}
+
+	return saveSessionStateFile(filepath.Join(deploymentDir, sessionStateFilename), state)
+}
+
+func (r *RemoteDevelopment) getSessionState() (*SessionState, error) {
+	stateFilePath, err := r.getSessionStateFilePath()
+	if err != nil {
+		return nil, err
+	}
+
+	return loadSessionStateFile(stateFilePath)
+}
+
+func (r *RemoteDevelopment) removeSessionState() error {
+	stateFilePath, err := r.getSessionStateFilePath()
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(stateFilePath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+func (r *RemoteDevelopment) getSessionStateIdentity() (string, error) {
+	if r.dockerEndpoint != nil {
+		return formatDockerSessionStateIdentity(r.dockerEndpoint.Container, r.sessionScope), nil
+	}
+
+	resource, err := r.getResource()
+	if err != nil {
+		return "", err
+	}
+
+	return formatSessionStateIdentity(resource.GetNamespace(), r.resourceType, resource.GetName(), r.sessionScope), nil
+}
+
+func formatSessionStateIdentity(namespace string, resourceType ResourceType, resourceName, sessionScope string) string {
+	identity := fmt.Sprintf("%s/%s/%s", namespace, resourceType, resourceName)
+	if sessionScope != "" {
+		identity = fmt.Sprintf("%s#%s", identity, sessionScope)
+	}
+
+	return identity
+}
+
+func formatDockerSessionStateIdentity(container, sessionScope string) string {
+	identity := mutagenDockerScheme + container
+	if sessionScope != "" {
+		identity = fmt.Sprintf("%s#%s", identity, sessionScope)
+	}
+
+	return identity
+}
+
+func (r *RemoteDevelopment) getSessionStateFilePath() (string, error) {
+	deploymentDir, err := r.getDeploymentWorkspaceDirPath()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(deploymentDir, sessionStateFilename), nil
+}
+
+// findSessionState looks the identity up across the deployment workspaces, the remote sync path
+// being part of the session key but unknown before attaching. The latest session wins.
+func findSessionState(identity string) (*SessionState, error) {
+	deploymentsDir, err := getDeploymentsDirPath()
+	if err != nil {
+		return nil, err
+	}
+
+	stateFilePaths, err := filepath.Glob(filepath.Join(deploymentsDir, "*", sessionStateFilename))
+	if err != nil {
+		return nil, err
+	}
+
+	var found *SessionState
+	for _, stateFilePath := range stateFilePaths {
+		state, err := loadSessionStateFile(stateFilePath)
+		if err != nil {
+			return nil, err
+		}
+
+		if state != nil && state.identity() == identity && (found == nil || state.StartedAt > found.StartedAt) {
+			found = state
+		}
+	}
+
+	return found, nil
+}
+
+// loadSessionStateFile returns nil when the file does not exist
+func loadSessionStateFile(stateFilePath string) (*SessionState, error) {
+	data, err := os.ReadFile(stateFilePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	state := &SessionState{}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("cannot read session state file %s: %w", stateFilePath, err)
+	}
+
+	return state, nil
+}
+
+func saveSessionStateFile(stateFilePath string, state SessionState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(stateFilePath, data, 0600)
+}
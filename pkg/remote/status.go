@@ -0,0 +1,68 @@
+package remote
+
+import (
+	"os"
+	"path/filepath"
+
+	"bunnyshell.com/dev/pkg/util"
+)
+
+const (
+	// prevents mutagen from starting the daemon as a side effect of a query
+	mutagenDisableAutostartEnv = "MUTAGEN_DISABLE_AUTOSTART=1"
+)
+
+// IsBinaryInstalled reports whether the mutagen binary is present in the workspace and its version
+func (r *RemoteDevelopment) IsBinaryInstalled() (bool, string) {
+	workspaceDir, err := util.GetRemoteDevWorkspaceDirPath()
+	if err != nil {
+		return false, ""
+	}
+
+	mutagenBinPath := filepath.Join(workspaceDir, getMutagenBinFilename())
+	stats, err := os.Stat(mutagenBinPath)
+	if err != nil || stats.IsDir() || stats.Size() == 0 {
+		return false, ""
+	}
+
+	version, err := getMutagenBinVersion(mutagenBinPath)
+	if err != nil {
+		return true, ""
+	}
+
+	return true, version
+}
+
+func (r *RemoteDevelopment) IsDaemonRunning() bool {
+	if installed, _ := r.IsBinaryInstalled(); !installed {
+		return false
+	}
+
+	_, err := r.queryMutagenSessions([]string{mutagenDisableAutostartEnv})
+
+	return err == nil
+}
+
+func (r *RemoteDevelopment) IsSessionActive() (bool, error) {
+	if !r.IsDaemonRunning() {
+		return false, nil
+	}
+
+	sessionName, err := r.getMutagenSessionName()
+	if err != nil {
+		return false, err
+	}
+
+	sessions, err := r.queryMutagenSessions([]string{mutagenDisableAutostartEnv})
+	if err != nil {
+		return false, err
+	}
+
+	for _, session := range sessions {
+		if session.Name == sessionName {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
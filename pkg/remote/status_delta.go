@@ -0,0 +1,88 @@
+package remote
+
+import (
+	"fmt"
+	"time"
+)
+
+// SessionStatus is a snapshot of the session, timestamped so two snapshots can be compared, see DiffStatus
+type SessionStatus struct {
+	Time    time.Time
+	Session MutagenSession
+
+	// CurrentFile is the file in flight, e.g. to spot a huge file stalling the sync, nil when nothing is staged
+	CurrentFile *StagingFile
+}
+
+// StatusDelta is the progress between two snapshots. Reset is set when the session was recreated in between,
+// the deltas are then zero and the current snapshot is the new baseline.
+type StatusDelta struct {
+	Elapsed time.Duration
+	Reset   bool
+
+	Cycles           uint64
+	TransferredFiles uint64
+	TransferredBytes uint64
+}
+
+// Throughput is the instantaneous transfer rate between the snapshots, in bytes per second
+func (d StatusDelta) Throughput() float64 {
+	if d.Elapsed <= 0 {
+		return 0
+	}
+
+	return float64(d.TransferredBytes) / d.Elapsed.Seconds()
+}
+
+// SessionStatus snapshots the current session
+func (r *RemoteDevelopment) SessionStatus() (*SessionStatus, error) {
+	session, err := r.getMutagenSession()
+	if err != nil {
+		return nil, err
+	}
+	if session == nil {
+		return nil, fmt.Errorf("mutagen session not found")
+	}
+
+	return &SessionStatus{Time: time.Now(), Session: *session, CurrentFile: session.currentFile()}, nil
+}
+
+// DiffStatus computes the progress from prev to cur. Mutagen resets the staging counters on every cycle,
+// so a decrease or a new cycle counts the current counters as transferred since prev, the transfers
+// completed between the snapshots being missed.
+func DiffStatus(prev, cur SessionStatus) StatusDelta {
+	delta := StatusDelta{Elapsed: cur.Time.Sub(prev.Time)}
+
+	if prev.Session.Identifier != cur.Session.Identifier || cur.Session.SuccessfulCycles < prev.Session.SuccessfulCycles {
+		delta.Reset = true
+		return delta
+	}
+
+	delta.Cycles = cur.Session.SuccessfulCycles - prev.Session.SuccessfulCycles
+
+	prevFiles, prevBytes := getStagedTotals(prev.Session)
+	curFiles, curBytes := getStagedTotals(cur.Session)
+	if delta.Cycles > 0 || curFiles < prevFiles || curBytes < prevBytes {
+		prevFiles, prevBytes = 0, 0
+	}
+
+	delta.TransferredFiles = curFiles - prevFiles
+	delta.TransferredBytes = curBytes - prevBytes
+
+	return delta
+}
+
+// getStagedTotals sums the staging counters of both endpoints, each staging the files it receives
+func getStagedTotals(session MutagenSession) (uint64, uint64) {
+	files, bytes := uint64(0), uint64(0)
+	for _, endpoint := range []MutagenEndpoint{session.Alpha, session.Beta} {
+		if endpoint.StagingProgress == nil {
+			continue
+		}
+
+		files += endpoint.StagingProgress.ReceivedFiles
+		bytes += endpoint.StagingProgress.TotalReceivedSize
+	}
+
+	return files, bytes
+}
@@ -0,0 +1,99 @@
+package remote
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// StatusFileSchemaVersion is bumped on incompatible changes of StatusFile
+	StatusFileSchemaVersion = 1
+
+	statusFilename = "status.json"
+)
+
+// StatusFile is written by the session monitors, WaitForSync and WatchAndRestart, on every poll,
+// for tools which cannot call the Go API. LastSync is omitted until a cycle is observed to complete.
+type StatusFile struct {
+	SchemaVersion int       `json:"schemaVersion"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+
+	Session  string     `json:"session"`
+	State    string     `json:"state"`
+	Paused   bool       `json:"paused"`
+	LastSync *time.Time `json:"lastSync,omitempty"`
+	Error    string     `json:"error,omitempty"`
+
+	Cycles     uint64  `json:"cycles"`
+	Conflicts  int     `json:"conflicts"`
+	Problems   int     `json:"problems"`
+	Throughput float64 `json:"throughput"`
+}
+
+// StatusFilePath returns the status file path of the session, in its deployment workspace
+func (r *RemoteDevelopment) StatusFilePath() (string, error) {
+	deploymentDir, err := r.getDeploymentWorkspaceDirPath()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(deploymentDir, statusFilename), nil
+}
+
+// writeStatusFile is best effort, a failure must not disturb the monitoring
+func (r *RemoteDevelopment) writeStatusFile(session *MutagenSession) {
+	if !r.statusFileEnabled {
+		return
+	}
+
+	if session.SuccessfulCycles != r.lastSyncCycles {
+		r.lastSyncCycles = session.SuccessfulCycles
+		r.lastSyncTime = time.Now()
+	}
+
+	var lastSync *time.Time
+	if !r.lastSyncTime.IsZero() {
+		lastSync = &r.lastSyncTime
+	}
+
+	summary := r.newSyncSummary(session)
+	status := StatusFile{
+		SchemaVersion: StatusFileSchemaVersion,
+		UpdatedAt:     time.Now(),
+
+		Session:  session.Name,
+		State:    session.Status,
+		Paused:   session.Paused,
+		LastSync: lastSync,
+		Error:    session.LastError,
+
+		Cycles:     session.SuccessfulCycles,
+		Conflicts:  summary.Conflicts,
+		Problems:   summary.Problems,
+		Throughput: summary.Throughput(),
+	}
+
+	data, err := json.MarshalIndent(status, "", "  ")
+	if err != nil {
+		return
+	}
+
+	if _, err := r.getDeploymentWorkspaceDir(); err != nil {
+		return
+	}
+
+	statusFilePath, err := r.StatusFilePath()
+	if err != nil {
+		return
+	}
+
+	// readers never see a partially written file
+	temporaryPath := statusFilePath + ".tmp"
+	if err := os.WriteFile(temporaryPath, data, 0600); err != nil {
+		return
+	}
+
+	os.Rename(temporaryPath, statusFilePath)
+}
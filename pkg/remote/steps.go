@@ -0,0 +1,47 @@
+package remote
+
+// Step identifies a setup step, its label is shown by the spinner and may be localized or rebranded
+// through WithStepLabels
+type Step string
+
+const (
+	StepGenerateSSHKey        Step = "generate-ssh-key"
+	StepSetupMutagen          Step = "setup-mutagen"
+	StepSetupSecret           Step = "setup-secret"
+	StepSetupPod              Step = "setup-pod"
+	StepWaitPodReady          Step = "wait-pod-ready"
+	StepStartSSHPortForward   Step = "start-ssh-port-forward"
+	StepCreateRemoteSyncPath  Step = "create-remote-sync-path"
+	StepVerifyRemoteSyncPath  Step = "verify-remote-sync-path"
+	StepInspectRemoteSyncPath Step = "inspect-remote-sync-path"
+	StepCheckCaseConflicts    Step = "check-case-conflicts"
+	StepStartMutagenSession   Step = "start-mutagen-session"
+)
+
+var defaultStepLabels = map[Step]string{
+	StepGenerateSSHKey:        "Generate SSH RSA key...",
+	StepSetupMutagen:          "Setup Mutagen",
+	StepSetupSecret:           "Setup k8s secret",
+	StepSetupPod:              "Setup k8s pod for remote development",
+	StepWaitPodReady:          "Waiting for pod to be ready",
+	StepStartSSHPortForward:   "Start Remote SSH Port Forward",
+	StepCreateRemoteSyncPath:  "Create Remote Sync Path",
+	StepVerifyRemoteSyncPath:  "Verify Remote Sync Path",
+	StepInspectRemoteSyncPath: "Inspect Remote Sync Path",
+	StepCheckCaseConflicts:    "Check Remote Path Case Conflicts",
+	StepStartMutagenSession:   "Start Mutagen Session",
+}
+
+// StepLabel returns the label of step, the default English one unless overridden
+func (r *RemoteDevelopment) StepLabel(step Step) string {
+	if label, ok := r.stepLabels[step]; ok {
+		return label
+	}
+
+	return defaultStepLabels[step]
+}
+
+// startStep is StartSpinner with the label of step
+func (r *RemoteDevelopment) startStep(step Step) {
+	r.StartSpinner(" " + r.StepLabel(step))
+}
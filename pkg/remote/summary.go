@@ -0,0 +1,160 @@
+package remote
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	mutagenConfig "bunnyshell.com/dev/pkg/mutagen/config"
+)
+
+// SyncSummary reports what a session synchronized, for contexts without a live UI such as CI.
+// Transfers are observed by polling the staging progress, files staged entirely between two polls are missed.
+type SyncSummary struct {
+	// Duration is measured from the start of the session, created or reused
+	Duration time.Duration
+	Cycles   uint64
+
+	// Files and TotalFileSize describe the synchronized tree of the local endpoint
+	Files         uint64
+	TotalFileSize uint64
+
+	TransferredFiles uint64
+	TransferredBytes uint64
+
+	Conflicts int
+	Problems  int
+}
+
+// Throughput is the average of the observed transfers over the session duration, in bytes per second
+func (s *SyncSummary) Throughput() float64 {
+	if s.Duration <= 0 {
+		return 0
+	}
+
+	return float64(s.TransferredBytes) / s.Duration.Seconds()
+}
+
+func (s *SyncSummary) String() string {
+	return fmt.Sprintf(
+		"%d files (%d bytes) in sync after %d cycles in %s, transferred %d files (%d bytes, %.0f bytes/s), %d conflicts, %d problems",
+		s.Files,
+		s.TotalFileSize,
+		s.Cycles,
+		s.Duration.Round(time.Second),
+		s.TransferredFiles,
+		s.TransferredBytes,
+		s.Throughput(),
+		s.Conflicts,
+		s.Problems,
+	)
+}
+
+// transferStats accumulates the staging progress across cycles, mutagen resets it for every cycle.
+// Both endpoints stage within a cycle, one after the other, so the progress is kept per endpoint and summed.
+type transferStats struct {
+	mutex sync.Mutex
+
+	cycle         uint64
+	cycleFiles    [2]uint64
+	cycleBytes    [2]uint64
+	previousFiles uint64
+	previousBytes uint64
+}
+
+func (t *transferStats) observe(session *MutagenSession) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if session.SuccessfulCycles != t.cycle {
+		files, bytes := t.cycleTotals()
+		t.previousFiles += files
+		t.previousBytes += bytes
+		t.cycle = session.SuccessfulCycles
+		t.cycleFiles, t.cycleBytes = [2]uint64{}, [2]uint64{}
+	}
+
+	for i, endpoint := range []MutagenEndpoint{session.Alpha, session.Beta} {
+		if endpoint.StagingProgress == nil {
+			continue
+		}
+
+		t.cycleFiles[i] = max(t.cycleFiles[i], endpoint.StagingProgress.ReceivedFiles)
+		t.cycleBytes[i] = max(t.cycleBytes[i], endpoint.StagingProgress.TotalReceivedSize)
+	}
+}
+
+func (t *transferStats) cycleTotals() (uint64, uint64) {
+	return t.cycleFiles[0] + t.cycleFiles[1], t.cycleBytes[0] + t.cycleBytes[1]
+}
+
+func (t *transferStats) totals() (uint64, uint64) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	files, bytes := t.cycleTotals()
+
+	return t.previousFiles + files, t.previousBytes + bytes
+}
+
+// Summary reports on the current session, see SyncSummary
+func (r *RemoteDevelopment) Summary() (*SyncSummary, error) {
+	session, err := r.getMutagenSession()
+	if err != nil {
+		return nil, err
+	}
+	if session == nil {
+		return nil, fmt.Errorf("mutagen session not found")
+	}
+
+	r.transferStats.observe(session)
+
+	return r.newSyncSummary(session), nil
+}
+
+func (r *RemoteDevelopment) newSyncSummary(session *MutagenSession) *SyncSummary {
+	transferredFiles, transferredBytes := r.transferStats.totals()
+
+	localEndpoint := session.Alpha
+	if r.reverse {
+		localEndpoint = session.Beta
+	}
+
+	duration := time.Duration(0)
+	if !r.sessionStartedAt.IsZero() {
+		duration = time.Since(r.sessionStartedAt)
+	}
+
+	return &SyncSummary{
+		Duration: duration,
+		Cycles:   session.SuccessfulCycles,
+
+		Files:         localEndpoint.Files,
+		TotalFileSize: localEndpoint.TotalFileSize,
+
+		TransferredFiles: transferredFiles,
+		TransferredBytes: transferredBytes,
+
+		Conflicts: len(session.Conflicts),
+		Problems:  len(session.Problems()),
+	}
+}
+
+// TerminateWithSummary is SafeTerminate, reporting on the session right before terminating it
+func (r *RemoteDevelopment) TerminateWithSummary(force bool) (*SyncSummary, error) {
+	if r.syncMode == mutagenConfig.None {
+		return &SyncSummary{}, nil
+	}
+
+	if err := r.FlushSession(context.Background()); err != nil && !force {
+		return nil, err
+	}
+
+	summary, err := r.Summary()
+	if err != nil {
+		return nil, err
+	}
+
+	return summary, r.SafeTerminate(force)
+}
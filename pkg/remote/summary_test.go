@@ -0,0 +1,40 @@
+package remote
+
+import "testing"
+
+func stagingSession(cycles uint64, alpha, beta *MutagenStagingProgress) *MutagenSession {
+	return &MutagenSession{
+		SuccessfulCycles: cycles,
+		Alpha:            MutagenEndpoint{StagingProgress: alpha},
+		Beta:             MutagenEndpoint{StagingProgress: beta},
+	}
+}
+
+func TestTransferStatsSumsEndpoints(t *testing.T) {
+	stats := transferStats{}
+
+	// alpha then beta stage within the first cycle, the alpha progress is gone once beta stages
+	stats.observe(stagingSession(0, &MutagenStagingProgress{ReceivedFiles: 2, TotalReceivedSize: 200}, nil))
+	stats.observe(stagingSession(0, nil, &MutagenStagingProgress{ReceivedFiles: 1, TotalReceivedSize: 50}))
+	stats.observe(stagingSession(0, nil, &MutagenStagingProgress{ReceivedFiles: 3, TotalReceivedSize: 300}))
+
+	if files, bytes := stats.totals(); files != 5 || bytes != 500 {
+		t.Errorf("got %d files, %d bytes within the cycle, want 5 files, 500 bytes", files, bytes)
+	}
+
+	// the progress restarts from zero for the next cycle
+	stats.observe(stagingSession(1, nil, &MutagenStagingProgress{ReceivedFiles: 1, TotalReceivedSize: 10}))
+	stats.observe(stagingSession(1, nil, nil))
+
+	if files, bytes := stats.totals(); files != 6 || bytes != 510 {
+		t.Errorf("got %d files, %d bytes across cycles, want 6 files, 510 bytes", files, bytes)
+	}
+}
+
+func TestSyncSummaryDurationFromSessionStart(t *testing.T) {
+	r := NewRemoteDevelopment()
+
+	if summary := r.newSyncSummary(&MutagenSession{}); summary.Duration != 0 {
+		t.Errorf("got duration %s before the session started, want 0", summary.Duration)
+	}
+}
@@ -0,0 +1,107 @@
+package remote
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"bunnyshell.com/dev/pkg/util"
+)
+
+const (
+	// syncLogMaxSize triggers the rotation, a single previous file is kept with the ".1" suffix
+	syncLogMaxSize = 10 << 20
+)
+
+// syncLog appends timestamped lines to a file rotated by size, for post-mortem debugging
+type syncLog struct {
+	mutex sync.Mutex
+
+	path string
+	file *os.File
+}
+
+func (l *syncLog) printf(format string, args ...any) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if err := l.open(); err != nil {
+		return
+	}
+
+	line := fmt.Sprintf(format, args...)
+	fmt.Fprintf(l.file, "%s %s\n", time.Now().Format(time.RFC3339Nano), strings.TrimSpace(line))
+}
+
+func (l *syncLog) open() error {
+	if l.file != nil {
+		stats, err := l.file.Stat()
+		if err == nil && stats.Size() < syncLogMaxSize {
+			return nil
+		}
+
+		l.file.Close()
+		l.file = nil
+		os.Rename(l.path, l.path+".1")
+	}
+
+	file, err := os.OpenFile(l.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	l.file = file
+
+	return nil
+}
+
+func (l *syncLog) close() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.file != nil {
+		l.file.Close()
+		l.file = nil
+	}
+}
+
+// logSync records a line tagged with the session name when a log file is configured, failures are ignored
+func (r *RemoteDevelopment) logSync(format string, args ...any) {
+	if r.syncLog == nil {
+		return
+	}
+
+	sessionName, err := r.getMutagenSessionName()
+	if err != nil {
+		sessionName = "-"
+	}
+
+	r.syncLog.printf("[%s] %s", sessionName, fmt.Sprintf(format, args...))
+}
+
+func (r *RemoteDevelopment) logSessionStatus(session *MutagenSession) {
+	r.logSync(
+		"status: %s, cycles %d, conflicts %d, problems %d, last error: %q",
+		session.Status,
+		session.SuccessfulCycles,
+		len(session.Conflicts),
+		len(session.Problems()),
+		session.LastError,
+	)
+}
+
+// getSyncLogPath resolves relative paths against the workspace
+func getSyncLogPath(logFile string) (string, error) {
+	if filepath.IsAbs(logFile) {
+		return logFile, nil
+	}
+
+	workspaceDir, err := util.GetRemoteDevWorkspaceDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(workspaceDir, logFile), nil
+}
@@ -0,0 +1,218 @@
+package remote
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"bunnyshell.com/dev/pkg/build"
+	mutagenConfig "bunnyshell.com/dev/pkg/mutagen/config"
+	"bunnyshell.com/dev/pkg/util"
+)
+
+// Validate checks the settings for consistency before any side effect, reporting all the problems at once
+func (r *RemoteDevelopment) Validate() error {
+	problems := []error{}
+	check := func(err error) {
+		if err != nil {
+			problems = append(problems, err)
+		}
+	}
+
+	switch r.syncMode {
+	case mutagenConfig.None, mutagenConfig.TwoWaySafe, mutagenConfig.TwoWayResolved, mutagenConfig.OneWaySafe, mutagenConfig.OneWayReplica:
+	default:
+		check(fmt.Errorf("invalid sync mode \"%s\"", r.syncMode))
+	}
+
+	if r.syncMode != mutagenConfig.None {
+		if localSyncPath, err := r.getResolvedLocalSyncPath(); err != nil {
+			check(err)
+		} else {
+			check(validateLocalSyncPath(localSyncPath, r.localSyncFile))
+		}
+
+		if !strings.HasPrefix(r.remoteSyncPath, "/") {
+			check(fmt.Errorf("remote sync path \"%s\" must be absolute", r.remoteSyncPath))
+		}
+	}
+
+	switch r.scanMode {
+	case "", mutagenConfig.ScanModeFull, mutagenConfig.ScanModeAccelerated:
+	default:
+		check(fmt.Errorf("invalid scan mode \"%s\"", r.scanMode))
+	}
+
+	switch r.configDriftPolicy {
+	case "", ConfigDriftIgnore, ConfigDriftWarn, ConfigDriftPrompt, ConfigDriftRecreate:
+	default:
+		check(fmt.Errorf("invalid config drift policy \"%s\"", r.configDriftPolicy))
+	}
+
+	for group := range r.ignoreGroups {
+		if !slices.Contains(mutagenConfig.IgnoreGroupOrder, group) {
+			check(fmt.Errorf("unknown ignore group \"%s\"", group))
+		}
+	}
+
+	if (r.sshPrivateKeyPath == "") != (r.sshPublicKeyPath == "") {
+		check(fmt.Errorf("the SSH private and public key paths must be set together"))
+	}
+
+	if r.sshOptions != nil {
+		check(r.sshOptions.Validate())
+	}
+
+	if r.dockerEndpoint != nil {
+		check(r.dockerEndpoint.Validate())
+	}
+
+	check(validateMutagenSessionNamePrefix(r.sessionNamePrefix))
+	if r.sessionName != "" {
+		check(validateMutagenSessionName(r.sessionName))
+	}
+
+	check(validateExtraCreateArgs(r.extraCreateArgs))
+
+	if r.priority < 0 || r.priority > maxNiceness {
+		check(fmt.Errorf("invalid priority %d, expected a nice increment of 0 to %d", r.priority, maxNiceness))
+	}
+
+	if r.binFileMode&0100 == 0 {
+		check(fmt.Errorf("mutagen binary file mode %o is not executable by the owner", r.binFileMode))
+	}
+
+	check(validateWorkspaceWritable())
+
+	tempDir := r.tempDir
+	if tempDir == "" {
+		tempDir = os.Getenv(TempDirEnvVar)
+	}
+	if tempDir != "" {
+		check(validateTempDir(tempDir))
+	}
+
+	if r.configFilePath != "" {
+		check(validateConfigFilePath(r.configFilePath))
+	}
+
+	// a bundled or system binary doesn't depend on the published releases
+	if r.bundledMutagenBinPath == "" && !r.preferSystemMutagen {
+		check(ensureMutagenReleasePlatform(build.MutagenVersion))
+	}
+
+	return errors.Join(problems...)
+}
+
+func validateLocalSyncPath(localSyncPath, localSyncFile string) error {
+	stats, err := os.Stat(localSyncPath)
+	if err != nil {
+		return fmt.Errorf("local sync path \"%s\" is not accessible: %w", localSyncPath, err)
+	}
+
+	if !stats.IsDir() {
+		return fmt.Errorf("local sync path \"%s\" is not a directory", localSyncPath)
+	}
+
+	if localSyncFile == "" {
+		return nil
+	}
+
+	fileStats, err := os.Stat(filepath.Join(localSyncPath, localSyncFile))
+	if err != nil {
+		return fmt.Errorf("local sync file \"%s\" is not accessible: %w", localSyncFile, err)
+	}
+
+	if !fileStats.Mode().IsRegular() {
+		return fmt.Errorf("local sync file \"%s\" is not a regular file", localSyncFile)
+	}
+
+	return nil
+}
+
+// validateWorkspaceWritable probes an existing workspace, a missing one is created by Up
+func validateWorkspaceWritable() error {
+	workspaceDir, err := util.GetRemoteDevWorkspaceDirPath()
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(workspaceDir); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	probe, err := os.CreateTemp(workspaceDir, ".write-probe-*")
+	if err != nil {
+		return fmt.Errorf("workspace \"%s\" is not writable: %w", workspaceDir, err)
+	}
+	probe.Close()
+
+	return os.Remove(probe.Name())
+}
+
+// validateConfigFilePath probes the closest existing ancestor of the config file, missing directories are created by Up
+func validateConfigFilePath(configFilePath string) error {
+	if stats, err := os.Stat(configFilePath); err == nil {
+		if stats.IsDir() {
+			return fmt.Errorf("mutagen config path \"%s\" is a directory", configFilePath)
+		}
+
+		file, err := os.OpenFile(configFilePath, os.O_WRONLY, 0)
+		if err != nil {
+			return fmt.Errorf("mutagen config path \"%s\" is not writable: %w", configFilePath, err)
+		}
+
+		return file.Close()
+	}
+
+	dir := filepath.Dir(configFilePath)
+	for {
+		stats, err := os.Stat(dir)
+		if err == nil {
+			if !stats.IsDir() {
+				return fmt.Errorf("mutagen config directory \"%s\" is not a directory", dir)
+			}
+
+			break
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("mutagen config directory \"%s\" is not accessible: %w", dir, err)
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return fmt.Errorf("mutagen config directory \"%s\" has no existing ancestor", dir)
+		}
+		dir = parent
+	}
+
+	probe, err := os.CreateTemp(dir, ".write-probe-*")
+	if err != nil {
+		return fmt.Errorf("mutagen config directory \"%s\" is not writable: %w", dir, err)
+	}
+	probe.Close()
+
+	return os.Remove(probe.Name())
+}
+
+func validateTempDir(tempDir string) error {
+	stats, err := os.Stat(tempDir)
+	if err != nil {
+		return fmt.Errorf("temp dir \"%s\" is not accessible: %w", tempDir, err)
+	}
+
+	if !stats.IsDir() {
+		return fmt.Errorf("temp dir \"%s\" is not a directory", tempDir)
+	}
+
+	probe, err := os.CreateTemp(tempDir, ".write-probe-*")
+	if err != nil {
+		return fmt.Errorf("temp dir \"%s\" is not writable: %w", tempDir, err)
+	}
+	probe.Close()
+
+	return os.Remove(probe.Name())
+}
@@ -0,0 +1,190 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	mutagenConfig "bunnyshell.com/dev/pkg/mutagen/config"
+)
+
+const (
+	watchInterval   = 5 * time.Second
+	watchMaxBackoff = 2 * time.Minute
+
+	defaultRecoveryGracePeriod = 10 * time.Second
+
+	// sleepGapThreshold is the wall clock time missing between two polls past which the machine is assumed
+	// to have slept. The monotonic clock stops during sleep, the wall clock doesn't.
+	sleepGapThreshold = 30 * time.Second
+	wakeFlushTimeout  = 30 * time.Second
+
+	configDriftCheckInterval = time.Minute
+)
+
+// pollClock tells a sleep from a slow poll: the gap is the timer's own overrun, measured from the moment
+// it was armed, so the time spent processing a poll, e.g. flushing or recreating the session, never counts
+type pollClock struct {
+	armedAt time.Time
+	delay   time.Duration
+}
+
+func (c *pollClock) arm(now time.Time, delay time.Duration) {
+	c.armedAt = now.Round(0)
+	c.delay = delay
+}
+
+func (c *pollClock) sleepGap(now time.Time) time.Duration {
+	return now.Round(0).Sub(c.armedAt) - c.delay
+}
+
+// WatchAndRestart keeps the mutagen session alive until ctx is cancelled.
+// An errored session is reset, a missing one is recreated, retrying with backoff.
+func (r *RemoteDevelopment) WatchAndRestart(ctx context.Context) error {
+	if r.syncMode == mutagenConfig.None {
+		<-ctx.Done()
+		return nil
+	}
+
+	delay := watchInterval
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	clock := pollClock{}
+	clock.arm(time.Now(), delay)
+	lastDriftCheck := clock.armedAt
+	lastReplacementCheck := clock.armedAt
+	for {
+		select {
+		case <-ctx.Done():
+			r.terminateMutagenSession()
+			r.removeSessionState()
+			return nil
+		case <-timer.C:
+		}
+
+		now := time.Now().Round(0)
+		if gap := clock.sleepGap(now); gap > sleepGapThreshold {
+			r.resumeAfterSleep(gap)
+		}
+
+		if now.Sub(lastReplacementCheck) >= podReplacementCheckInterval {
+			lastReplacementCheck = now
+			if err := r.handleRemoteReplacement(); err != nil {
+				fmt.Printf("WARNING: cannot re-synchronize the replaced remote container: %s\n", err)
+			}
+		}
+
+		if now.Sub(lastDriftCheck) >= configDriftCheckInterval {
+			lastDriftCheck = now
+			if recreate, err := r.shouldRecreateOnConfigDrift(false); err != nil {
+				fmt.Printf("WARNING: cannot check the mutagen config drift: %s\n", err)
+			} else if recreate {
+				if err := r.RestartSession(); err != nil {
+					fmt.Printf("WARNING: cannot recreate the mutagen session: %s\n", err)
+				}
+			}
+		}
+
+		if err := r.recoverMutagenSession(); err != nil {
+			fmt.Printf("WARNING: mutagen session recovery failed, retrying in %s: %s\n", delay, err)
+			delay = min(delay*2, watchMaxBackoff)
+		} else {
+			delay = watchInterval
+		}
+
+		clock.arm(time.Now(), delay)
+		timer.Reset(delay)
+	}
+}
+
+func (r *RemoteDevelopment) recoverMutagenSession() error {
+	session, err := r.getMutagenSession()
+	if err != nil {
+		return err
+	}
+
+	if session != nil {
+		r.logSessionStatus(session)
+		r.notifyConflicts(session.Conflicts)
+		r.transferStats.observe(session)
+		r.writeStatusFile(session)
+	}
+
+	// a paused session was paused on purpose
+	if session != nil && (session.IsHealthy() || session.Paused) {
+		r.unhealthySince = time.Time{}
+		return nil
+	}
+
+	// mutagen often resolves transient states on its own, e.g. while reconnecting
+	if r.unhealthySince.IsZero() {
+		r.unhealthySince = time.Now()
+	}
+	if time.Since(r.unhealthySince) < r.recoveryGracePeriod {
+		return nil
+	}
+	r.unhealthySince = time.Time{}
+
+	if session != nil {
+		fmt.Printf("INFO: mutagen session %s errored (%s), resetting\n", session.Name, session.LastError)
+		if err := r.resetMutagenSession(); err == nil {
+			return nil
+		}
+
+		if err := r.terminateMutagenSession(); err != nil {
+			return err
+		}
+	}
+
+	sessionName, err := r.getMutagenSessionName()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("INFO: recreating mutagen session %s\n", sessionName)
+
+	return r.createMutagenSession()
+}
+
+// resumeAfterSleep forces a scan, mutagen's watch may have missed the changes made around the sleep.
+// The session is reset when the flush fails, e.g. when the watch stopped delivering events.
+func (r *RemoteDevelopment) resumeAfterSleep(gap time.Duration) {
+	fmt.Printf("INFO: resuming after %s of sleep, reconciling the changes\n", gap.Round(time.Second))
+
+	ctx, cancel := context.WithTimeout(context.Background(), wakeFlushTimeout)
+	defer cancel()
+
+	if err := r.FlushSession(ctx); err == nil || errors.Is(err, ErrFlushTimeout) {
+		return
+	}
+
+	if err := r.resetMutagenSession(); err != nil {
+		fmt.Printf("WARNING: cannot reset the mutagen session after sleep: %s\n", err)
+	}
+}
+
+// notifyConflicts fires the conflict callback with the conflicts not reported yet.
+// A resolved conflict is forgotten, so it is reported again if it reappears.
+func (r *RemoteDevelopment) notifyConflicts(conflicts []MutagenConflict) {
+	if r.onConflict == nil {
+		return
+	}
+
+	r.conflictsMutex.Lock()
+	current := map[string]bool{}
+	newConflicts := []MutagenConflict{}
+	for _, conflict := range conflicts {
+		current[conflict.Root] = true
+		if !r.reportedConflicts[conflict.Root] {
+			newConflicts = append(newConflicts, conflict)
+		}
+	}
+	r.reportedConflicts = current
+	r.conflictsMutex.Unlock()
+
+	if len(newConflicts) > 0 {
+		r.onConflict(newConflicts)
+	}
+}
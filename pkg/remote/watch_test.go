@@ -0,0 +1,30 @@
+package remote
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPollClockIgnoresProcessingTime(t *testing.T) {
+	start := time.Now()
+	clock := pollClock{}
+
+	clock.arm(start, watchInterval)
+	firedAt := start.Add(watchInterval)
+	if gap := clock.sleepGap(firedAt); gap > sleepGapThreshold {
+		t.Fatalf("got a sleep gap of %s on time", gap)
+	}
+
+	// the poll outlasts the threshold, e.g. a timed out wake flush followed by a session recreate
+	processedAt := firedAt.Add(wakeFlushTimeout + 20*time.Second)
+	clock.arm(processedAt, watchInterval)
+	if gap := clock.sleepGap(processedAt.Add(watchInterval)); gap > sleepGapThreshold {
+		t.Errorf("got a sleep gap of %s after a slow poll, want no resume", gap)
+	}
+
+	// the timer firing late is a sleep
+	clock.arm(processedAt, watchInterval)
+	if gap := clock.sleepGap(processedAt.Add(watchInterval + 2*time.Minute)); gap != 2*time.Minute {
+		t.Errorf("got a sleep gap of %s, want 2m", gap)
+	}
+}
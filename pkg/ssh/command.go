@@ -0,0 +1,99 @@
+package ssh
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// ErrConnectionFailed wraps the errors reaching the server, as opposed to the command failing
+var ErrConnectionFailed = errors.New("cannot connect to the ssh server")
+
+func RunCommand(server *Endpoint, auth ssh.AuthMethod, command string) ([]byte, error) {
+	return runCommand(server, auth, command, (*ssh.Session).CombinedOutput)
+}
+
+// RunCommandOutput returns the stdout of command only, e.g. when the output is compared
+// and warnings printed on stderr must not alter it
+func RunCommandOutput(server *Endpoint, auth ssh.AuthMethod, command string) ([]byte, error) {
+	return runCommand(server, auth, command, (*ssh.Session).Output)
+}
+
+func runCommand(server *Endpoint, auth ssh.AuthMethod, command string, run func(*ssh.Session, string) ([]byte, error)) ([]byte, error) {
+	config := &ssh.ClientConfig{
+		User:            server.User,
+		Auth:            []ssh.AuthMethod{auth},
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+	}
+
+	client, err := ssh.Dial("tcp", server.String(), config)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrConnectionFailed, err)
+	}
+	defer client.Close()
+
+	session, err := client.NewSession()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrConnectionFailed, err)
+	}
+	defer session.Close()
+
+	return run(session, command)
+}
+
+// MeasureRoundTrip times a keepalive request over an established connection, the handshake excluded
+func MeasureRoundTrip(server *Endpoint, auth ssh.AuthMethod) (time.Duration, error) {
+	config := &ssh.ClientConfig{
+		User:            server.User,
+		Auth:            []ssh.AuthMethod{auth},
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+	}
+
+	client, err := ssh.Dial("tcp", server.String(), config)
+	if err != nil {
+		return 0, err
+	}
+	defer client.Close()
+
+	start := time.Now()
+	if _, _, err := client.SendRequest("keepalive@openssh.com", true, nil); err != nil {
+		return 0, err
+	}
+
+	return time.Since(start), nil
+}
+
+// errHostKeyFetched aborts the handshake once the host key is known, no authentication is attempted
+var errHostKeyFetched = errors.New("host key fetched")
+
+// FetchHostKey returns the host key presented by server
+func FetchHostKey(server *Endpoint) (ssh.PublicKey, error) {
+	var hostKey ssh.PublicKey
+
+	config := &ssh.ClientConfig{
+		User: server.User,
+		HostKeyCallback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			hostKey = key
+			return errHostKeyFetched
+		},
+	}
+
+	client, err := ssh.Dial("tcp", server.String(), config)
+	if err == nil {
+		client.Close()
+	}
+	if hostKey == nil {
+		return nil, fmt.Errorf("cannot fetch the host key of %s: %w", server, err)
+	}
+
+	return hostKey, nil
+}
+
+// QuoteArg wraps value in single quotes so it is passed verbatim to a POSIX shell
+func QuoteArg(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
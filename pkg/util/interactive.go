@@ -30,6 +30,17 @@ func Ask(question, defaultInput string) (string, error) {
 	return answer, err
 }
 
+func Confirm(question string, defaultAnswer bool) (bool, error) {
+	answer := defaultAnswer
+	prompt := &survey.Confirm{
+		Message: question,
+		Default: defaultAnswer,
+	}
+	err := survey.AskOne(prompt, &answer)
+
+	return answer, err
+}
+
 func AskPath(question string, value string, validate survey.Validator) (string, error) {
 	answer := ""
 
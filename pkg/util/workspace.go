@@ -29,6 +29,20 @@ func GetRemoteDevWorkspaceDir() (string, error) {
 	return path, nil
 }
 
+// GetRemoteDevWorkspaceDirPath resolves the workspace path without creating it
+func GetRemoteDevWorkspaceDirPath() (string, error) {
+	if remoteDevWorkspace != nil {
+		return *remoteDevWorkspace, nil
+	}
+
+	workspaceDir, err := getWorkspaceDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(workspaceDir, RemoteDevDirname), nil
+}
+
 func getWorkspaceDir() (string, error) {
 	home, err := os.UserHomeDir()
 	if err != nil {